@@ -1,13 +1,17 @@
 package fatsecret
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"strings"
 )
 
 // FoodBrands is a component of the 'food_brands.get' API response data
 type FoodBrands struct {
-	Brands []string `json:"food_brand"`
+	Brands []string `json:"food_brand" xml:"food_brand"`
 }
 
 // FoodBrandsResponse is the response format of the 'food_brands.get' API call
@@ -16,6 +20,34 @@ type FoodBrandsResponse struct {
 	Error  *ErrorResponse `json:"error,omitempty"`
 }
 
+// UnmarshalJSON handles the API returning a single brand, rather than
+// an array, when there is only one match
+func (b *FoodBrands) UnmarshalJSON(data []byte) error {
+	raw := struct {
+		Brands json.RawMessage `json:"food_brand"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	b.Brands = nil
+	return unmarshalJSONArray(raw.Brands, &b.Brands)
+}
+
+// UnmarshalXML decodes either a '<food_brands>' or an '<error>' response
+func (r *FoodBrandsResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if isXMLError(start) {
+		r.Error = &ErrorResponse{}
+		return d.DecodeElement(r.Error, &start)
+	}
+	r.Brands = &FoodBrands{}
+	return d.DecodeElement(r.Brands, &start)
+}
+
+// MarshalXML encodes either a '<food_brands>' or an '<error>' response
+func (r FoodBrandsResponse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalXMLResponse(e, r.Error, "food_brands", r.Brands)
+}
+
 // BrandType is the enum type
 type BrandType int
 
@@ -28,26 +60,74 @@ const (
 	BrandTypeSupermarket
 )
 
+// String returns the API name of the brand type (ie: "restaurant")
+func (t BrandType) String() string {
+	switch t {
+	case BrandTypeManufacturer:
+		return "manufacturer"
+	case BrandTypeRestaurant:
+		return "restaurant"
+	case BrandTypeSupermarket:
+		return "supermarket"
+	}
+	return fmt.Sprintf("BrandType(%d)", int(t))
+}
+
+// ParseBrandType converts the given API name (ie: "restaurant") into
+// the associated brand type. The name is not case sensitive.
+func ParseBrandType(name string) (BrandType, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "manufacturer":
+		return BrandTypeManufacturer, nil
+	case "restaurant":
+		return BrandTypeRestaurant, nil
+	case "supermarket":
+		return BrandTypeSupermarket, nil
+	}
+	return 0, fmt.Errorf("Invalid brand type '%s' given", name)
+}
+
+// MarshalText encodes the brand type as its API name
+func (t BrandType) MarshalText() ([]byte, error) {
+	if t < BrandTypeManufacturer || t > BrandTypeSupermarket {
+		return nil, fmt.Errorf("Invalid brand type '%d' given", int(t))
+	}
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes the brand type from its API name
+func (t *BrandType) UnmarshalText(text []byte) error {
+	brandType, err := ParseBrandType(string(text))
+	if err != nil {
+		return err
+	}
+	*t = brandType
+	return nil
+}
+
 // FoodBrandsByType invokes the FatSecret 'food_brands.get' API call using
 // the 'brand_type' parameter and returns the response as a slice of brand strings
-func (c *Client) FoodBrandsByType(brandType BrandType) ([]string, error) {
-	// get the brand type name string
-	name := brandTypeName(brandType)
-
-	// invoke the api call
-	body, err := c.InvokeAPI(
-		"food_brands.get",
-		map[string]string{
-			"brand_type": name,
-		},
-	)
+func (c *Client) FoodBrandsByType(brandType BrandType) (brands []string, err error) {
+	// validate the brand type
+	name, err := brandType.MarshalText()
 	if err != nil {
 		return nil, err
 	}
 
-	// parse the api response
+	// trace the call
+	ctx, span := c.startCall(context.Background(), "FoodBrandsByType", StringAttribute(AttrBrandType, string(name)))
+	defer func() { c.endSpan(span, err) }()
+
+	// invoke the api call, decoding the response
 	brandsResp := FoodBrandsResponse{}
-	if err := json.Unmarshal(body, &brandsResp); err != nil {
+	if err := c.invokeDecode(
+		ctx,
+		"food_brands.get",
+		map[string]string{
+			"brand_type": string(name),
+		},
+		&brandsResp,
+	); err != nil {
 		return nil, err
 	}
 
@@ -62,21 +142,62 @@ func (c *Client) FoodBrandsByType(brandType BrandType) ([]string, error) {
 
 // FoodBrandsStartingWith invokes the FatSecret 'food_brands.get' API call using
 // the 'starts_with' parameter and returns the response as a slice of brand strings
-func (c *Client) FoodBrandsStartingWith(startsWith string) ([]string, error) {
-	// invoke the api call
-	body, err := c.InvokeAPI(
+func (c *Client) FoodBrandsStartingWith(startsWith string) (brands []string, err error) {
+	// trace the call
+	ctx, span := c.startCall(context.Background(), "FoodBrandsStartingWith", StringAttribute(AttrStartsWith, startsWith))
+	defer func() { c.endSpan(span, err) }()
+
+	// invoke the api call, decoding the response
+	brandsResp := FoodBrandsResponse{}
+	if err := c.invokeDecode(
+		ctx,
 		"food_brands.get",
 		map[string]string{
 			"starts_with": startsWith,
 		},
+		&brandsResp,
+	); err != nil {
+		return nil, err
+	}
+
+	// if an error response was returned
+	if brandsResp.Error != nil {
+		// return the response error message
+		return nil, errors.New(brandsResp.Error.Message)
+	}
+
+	return brandsResp.Brands.Brands, nil
+}
+
+// BrandQuery holds the filters of a combined 'food_brands.get' API call
+type BrandQuery struct {
+	Type       BrandType // the brand type (the API defaults to manufacturer)
+	StartsWith string    // the starting letter of the brands, or '*' for numbers
+	Region     string    // the optional region code (ie: "US")
+}
+
+// brandStartingChars are the 'starts_with' values which cover all brands
+const brandStartingChars = "*ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// FoodBrands invokes the FatSecret 'food_brands.get' API call sending all of
+// the given query filters together and returns a slice of brand strings
+func (c *Client) FoodBrands(ctx context.Context, q BrandQuery) (brands []string, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "FoodBrands",
+		StringAttribute(AttrBrandType, q.Type.String()),
+		StringAttribute(AttrStartsWith, q.StartsWith),
 	)
+	defer func() { c.endSpan(span, err) }()
+
+	// build the api parameters
+	params, err := q.params()
 	if err != nil {
 		return nil, err
 	}
 
-	// parse the api response
+	// invoke the api call, decoding the response
 	brandsResp := FoodBrandsResponse{}
-	if err := json.Unmarshal(body, &brandsResp); err != nil {
+	if err := c.invokeDecode(ctx, "food_brands.get", params, &brandsResp); err != nil {
 		return nil, err
 	}
 
@@ -86,26 +207,61 @@ func (c *Client) FoodBrandsStartingWith(startsWith string) ([]string, error) {
 		return nil, errors.New(brandsResp.Error.Message)
 	}
 
+	// if no brands were found
+	if brandsResp.Brands == nil {
+		return nil, nil
+	}
+
 	return brandsResp.Brands.Brands, nil
 }
 
-// brandTypeName converts the given enum type into the
-// associated string name
-func brandTypeName(brandType BrandType) string {
-	// determine the brand type name
-	var name string
-	switch brandType {
-	case BrandTypeManufacturer:
-		name = "manufacturer"
-	case BrandTypeRestaurant:
-		name = "restaurant"
-	case BrandTypeSupermarket:
-		name = "supermarket"
-	default:
-		// use 'manufacturer' by default, like the API does
-		name = "manufacturer"
+// AllBrands walks every starting character ('*' and A-Z) for the given
+// brand type and returns the de-duplicated brands in the order found
+func (c *Client) AllBrands(ctx context.Context, brandType BrandType) (brands []string, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "AllBrands", StringAttribute(AttrBrandType, brandType.String()))
+	defer func() { c.endSpan(span, err) }()
+
+	brands = []string{}
+	seen := map[string]bool{}
+	for _, ch := range brandStartingChars {
+		// fetch the brands starting with the next character
+		page, err := c.FoodBrands(ctx, BrandQuery{
+			Type:       brandType,
+			StartsWith: string(ch),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// add the brands which have not been seen yet
+		for _, b := range page {
+			if !seen[b] {
+				seen[b] = true
+				brands = append(brands, b)
+			}
+		}
+	}
+	return brands, nil
+}
+
+// params builds the api parameters for the query
+func (q BrandQuery) params() (map[string]string, error) {
+	// validate the brand type
+	name, err := q.Type.MarshalText()
+	if err != nil {
+		return nil, err
 	}
 
-	// return the brand type name
-	return name
+	// add the optional filters
+	params := map[string]string{
+		"brand_type": string(name),
+	}
+	if q.StartsWith != "" {
+		params["starts_with"] = q.StartsWith
+	}
+	if q.Region != "" {
+		params["region"] = q.Region
+	}
+	return params, nil
 }
@@ -1,6 +1,8 @@
 package fatsecret
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"testing"
@@ -21,14 +23,13 @@ func TestBrandTypeName(t *testing.T) {
 		{BrandTypeManufacturer, "manufacturer"},
 		{BrandTypeRestaurant, "restaurant"},
 		{BrandTypeSupermarket, "supermarket"},
-		{42, "manufacturer"}, // test default is 'manufacturer'
 	}
 
 	// iterate through each test-case
 	for _, tc := range testCases {
 		// run the next sub-test
 		t.Run(fmt.Sprintf("Brand Type for '%s' (%v)", tc.name, tc.brandType), func(t *testing.T) {
-			name := brandTypeName(tc.brandType)
+			name := tc.brandType.String()
 			if name != tc.name {
 				t.Errorf("got '%s'; want '%s'", name, tc.name)
 			}
@@ -36,7 +37,127 @@ func TestBrandTypeName(t *testing.T) {
 	}
 }
 
+func TestFoodBrandsByTypeInvalid(t *testing.T) {
+	// create a client, which is not called for an invalid brand type
+	c, err := NewClient("key", "secret", WithAPIURL("http://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// invalid brand types are rejected
+	brands, err := c.FoodBrandsByType(42)
+	if err == nil {
+		t.Errorf("got '%v'; want an error", brands)
+	}
+}
+
+func TestParseBrandType(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		name      string
+		brandType BrandType
+		valid     bool
+	}{
+		{"manufacturer", BrandTypeManufacturer, true},
+		{"restaurant", BrandTypeRestaurant, true},
+		{"Supermarket", BrandTypeSupermarket, true},
+		{"bakery", 0, false},
+		{"", 0, false},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(fmt.Sprintf("Parse brand type '%s'", tc.name), func(t *testing.T) {
+			brandType, err := ParseBrandType(tc.name)
+			if !tc.valid {
+				if err == nil {
+					t.Errorf("got '%v'; want an error", brandType)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: '%v'", err)
+			}
+			if brandType != tc.brandType {
+				t.Errorf("got '%v'; want '%v'", brandType, tc.brandType)
+			}
+		})
+	}
+}
+
+func TestBrandTypeJSON(t *testing.T) {
+	// round-trip each brand type through json
+	for _, brandType := range []BrandType{BrandTypeManufacturer, BrandTypeRestaurant, BrandTypeSupermarket} {
+		data, err := json.Marshal(brandType)
+		if err != nil {
+			t.Fatalf("Could not encode brand type: '%v'", err)
+		}
+		if string(data) != `"`+brandType.String()+`"` {
+			t.Errorf("got '%s'; want '\"%s\"'", data, brandType)
+		}
+		var decoded BrandType
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Could not decode brand type: '%v'", err)
+		}
+		if decoded != brandType {
+			t.Errorf("got '%v'; want '%v'", decoded, brandType)
+		}
+	}
+
+	// invalid brand types are rejected
+	if _, err := json.Marshal(BrandType(42)); err == nil {
+		t.Errorf("got no error encoding an invalid brand type")
+	}
+	var decoded BrandType
+	if err := json.Unmarshal([]byte(`"bakery"`), &decoded); err == nil {
+		t.Errorf("got no error decoding an invalid brand type")
+	}
+}
+
+func TestBrandQueryParams(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		query  BrandQuery
+		params map[string]string
+	}{
+		{BrandQuery{}, map[string]string{"brand_type": "manufacturer"}},
+		{
+			BrandQuery{Type: BrandTypeRestaurant, StartsWith: "b"},
+			map[string]string{"brand_type": "restaurant", "starts_with": "b"},
+		},
+		{
+			BrandQuery{Type: BrandTypeSupermarket, StartsWith: "*", Region: "UK"},
+			map[string]string{"brand_type": "supermarket", "starts_with": "*", "region": "UK"},
+		},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(fmt.Sprintf("Brand query %+v", tc.query), func(t *testing.T) {
+			params, err := tc.query.params()
+			if err != nil {
+				t.Fatalf("unexpected error: '%v'", err)
+			}
+			if fmt.Sprint(params) != fmt.Sprint(tc.params) {
+				t.Errorf("got %v; want %v", params, tc.params)
+			}
+		})
+	}
+
+	// invalid brand types are rejected rather than defaulted
+	if _, err := (BrandQuery{Type: 42}).params(); err == nil {
+		t.Errorf("got no error for an invalid brand type")
+	}
+}
+
 func TestFoodBrandsByType(t *testing.T) {
+	// skip the live api test without credentials
+	if consumerKey == "" || sharedSecret == "" {
+		t.Skip("FATSECRET_CONSUMER_KEY and FATSECRET_CONSUMER_SECRET are not set")
+	}
+
 	// define the test-cases
 	testCases := []struct {
 		brandType BrandType
@@ -45,7 +166,6 @@ func TestFoodBrandsByType(t *testing.T) {
 		{BrandTypeManufacturer, "manufacturer"},
 		{BrandTypeRestaurant, "restaurant"},
 		{BrandTypeSupermarket, "supermarket"},
-		{42, "manufacturer"}, // test default is 'manufacturer'
 	}
 
 	// create a fatsecret client
@@ -73,6 +193,11 @@ func TestFoodBrandsByType(t *testing.T) {
 }
 
 func TestFoodBrandsStartingWith(t *testing.T) {
+	// skip the live api test without credentials
+	if consumerKey == "" || sharedSecret == "" {
+		t.Skip("FATSECRET_CONSUMER_KEY and FATSECRET_CONSUMER_SECRET are not set")
+	}
+
 	// define the test-cases
 	testCases := []struct {
 		brandType BrandType
@@ -81,7 +206,6 @@ func TestFoodBrandsStartingWith(t *testing.T) {
 		{BrandTypeManufacturer, "manufacturer"},
 		{BrandTypeRestaurant, "restaurant"},
 		{BrandTypeSupermarket, "supermarket"},
-		{42, "manufacturer"}, // test default is 'manufacturer'
 	}
 
 	// define all of the starting characters
@@ -104,8 +228,11 @@ func TestFoodBrandsStartingWith(t *testing.T) {
 			startsWith := string(c)
 			t.Run(fmt.Sprintf("Brands of type '%s' starting with '%s'", tc.name, startsWith), func(t *testing.T) {
 
-				// invoke the api call
-				brands, err := client.FoodBrandsStartingWith(startsWith)
+				// invoke the api call using both the brand type and starting character
+				brands, err := client.FoodBrands(context.Background(), BrandQuery{
+					Type:       tc.brandType,
+					StartsWith: startsWith,
+				})
 				if err != nil {
 					t.Errorf("Could not fetch brands: '%v'", err)
 				}
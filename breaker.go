@@ -0,0 +1,227 @@
+package fatsecret
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling the API while the
+// circuit breaker is open and no cached response is available
+var ErrCircuitOpen = errors.New("FatSecret circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // calls are sent to the API
+	CircuitOpen                         // calls fail fast with ErrCircuitOpen
+	CircuitHalfOpen                     // a few probe calls are sent to the API
+)
+
+// String returns the name of the circuit state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerConfig configures the circuit breaker. Zero fields use
+// the defaults.
+type CircuitBreakerConfig struct {
+	FailureRatio   float64       // the ratio of failed calls which trips the breaker (default 0.5)
+	MinRequests    int           // the calls needed in the window before it can trip (default 10)
+	Window         time.Duration // the period over which calls are counted (default 1 minute)
+	OpenTimeout    time.Duration // how long the breaker stays open before probing (default 30 seconds)
+	HalfOpenProbes int           // the successful probes needed to close the breaker (default 1)
+}
+
+// the circuit breaker defaults
+const (
+	defaultFailureRatio   = 0.5
+	defaultMinRequests    = 10
+	defaultBreakerWindow  = time.Minute
+	defaultOpenTimeout    = 30 * time.Second
+	defaultHalfOpenProbes = 1
+)
+
+// WithCircuitBreaker fails API calls fast with ErrCircuitOpen once the
+// ratio of calls failing with a transport error or a 5xx status reaches
+// the configured ratio. After the open timeout a few probe calls are
+// let through, which close the breaker when they succeed. While the
+// breaker is open, stale responses are served from the cache, if any.
+func WithCircuitBreaker(cfg CircuitBreakerConfig) Option {
+	return func(c *Client) {
+		c.breaker = newBreaker(cfg)
+	}
+}
+
+// CircuitState returns the state of the client's circuit breaker, which
+// is always closed without one
+func (c *Client) CircuitState() CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.currentState(time.Now())
+}
+
+// breaker is a circuit breaker counting the outcome of the API calls
+type breaker struct {
+	cfg CircuitBreakerConfig
+	now func() time.Time
+
+	mu          sync.Mutex
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int    // probes in flight while half-open
+	successes   int    // successful probes while half-open
+	generation  uint64 // incremented on each change of state
+}
+
+// admission identifies an allowed call, so that its outcome is only
+// counted in the state which allowed it
+type admission struct {
+	generation uint64 // the generation of the breaker which allowed the call
+	probe      bool   // whether the call is a half-open probe
+}
+
+// newBreaker creates a closed circuit breaker, applying the defaults
+func newBreaker(cfg CircuitBreakerConfig) *breaker {
+	if cfg.FailureRatio <= 0 {
+		cfg.FailureRatio = defaultFailureRatio
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = defaultMinRequests
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultBreakerWindow
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = defaultOpenTimeout
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = defaultHalfOpenProbes
+	}
+	return &breaker{cfg: cfg, now: time.Now}
+}
+
+// currentState returns the state, moving an expired open breaker to
+// half-open. The lock must not be held.
+func (b *breaker) currentState(now time.Time) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire(now)
+	return b.state
+}
+
+// expire moves an open breaker past its timeout to half-open. The lock
+// must be held.
+func (b *breaker) expire(now time.Time) {
+	if b.state == CircuitOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.state = CircuitHalfOpen
+		b.generation++
+		b.probes = 0
+		b.successes = 0
+	}
+}
+
+// allow reports whether a call may be sent to the API, returning the
+// admission of the allowed call
+func (b *breaker) allow() (admission, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire(b.now())
+
+	adm := admission{generation: b.generation}
+	switch b.state {
+	case CircuitOpen:
+		return adm, false
+	case CircuitHalfOpen:
+		// only let the probe calls through
+		if b.probes+b.successes >= b.cfg.HalfOpenProbes {
+			return adm, false
+		}
+		b.probes++
+		adm.probe = true
+	}
+	return adm, true
+}
+
+// record counts the outcome of an allowed call. The outcome of a call
+// allowed before the last change of state is ignored.
+func (b *breaker) record(adm admission, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if adm.generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case CircuitHalfOpen:
+		// a failed probe opens the breaker again and enough successful
+		// probes close it
+		b.probes--
+		if failed {
+			b.open(now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenProbes {
+			b.state = CircuitClosed
+			b.generation++
+			b.resetWindow(now)
+		}
+
+	case CircuitClosed:
+		// count the call in the current window
+		if now.Sub(b.windowStart) >= b.cfg.Window {
+			b.resetWindow(now)
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+
+		// trip once enough calls have failed
+		if b.requests >= b.cfg.MinRequests && float64(b.failures)/float64(b.requests) >= b.cfg.FailureRatio {
+			b.open(now)
+		}
+	}
+}
+
+// open trips the breaker. The lock must be held.
+func (b *breaker) open(now time.Time) {
+	b.state = CircuitOpen
+	b.generation++
+	b.openedAt = now
+	b.probes = 0
+	b.successes = 0
+}
+
+// resetWindow starts a new counting window. The lock must be held.
+func (b *breaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
+
+// abandon releases an allowed call whose outcome is not counted, such
+// as one cancelled by the caller
+func (b *breaker) abandon(adm admission) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if adm.probe && adm.generation == b.generation {
+		b.probes--
+	}
+}
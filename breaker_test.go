@@ -0,0 +1,145 @@
+package fatsecret
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBreaker(CircuitBreakerConfig{
+		FailureRatio:   0.5,
+		MinRequests:    4,
+		Window:         time.Minute,
+		OpenTimeout:    10 * time.Second,
+		HalfOpenProbes: 2,
+	})
+	b.now = func() time.Time { return now }
+
+	// the breaker stays closed until the minimum requests are counted
+	for _, failed := range []bool{true, true, false} {
+		adm, ok := b.allow()
+		if !ok {
+			t.Fatalf("got call refused; want it allowed while closed")
+		}
+		b.record(adm, failed)
+	}
+	if got := b.currentState(now); got != CircuitClosed {
+		t.Fatalf("got state '%v'; want 'closed'", got)
+	}
+
+	// the failure ratio trips the breaker
+	adm, _ := b.allow()
+	b.record(adm, false)
+	if got := b.currentState(now); got != CircuitOpen {
+		t.Fatalf("got state '%v'; want 'open'", got)
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatalf("got call allowed; want it refused while open")
+	}
+
+	// after the open timeout only the probes are allowed
+	now = now.Add(10 * time.Second)
+	first, ok1 := b.allow()
+	second, ok2 := b.allow()
+	if !ok1 || !ok2 {
+		t.Fatalf("got probe refused; want two probes allowed")
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatalf("got call allowed; want it refused while probing")
+	}
+
+	// a failed probe opens the breaker again
+	b.record(first, false)
+	b.record(second, true)
+	if got := b.currentState(now); got != CircuitOpen {
+		t.Fatalf("got state '%v'; want 'open'", got)
+	}
+
+	// successful probes close the breaker
+	now = now.Add(10 * time.Second)
+	for i := 0; i < 2; i++ {
+		adm, ok := b.allow()
+		if !ok {
+			t.Fatalf("got probe refused; want it allowed")
+		}
+		b.record(adm, false)
+	}
+	if got := b.currentState(now); got != CircuitClosed {
+		t.Fatalf("got state '%v'; want 'closed'", got)
+	}
+}
+
+func TestBreakerWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBreaker(CircuitBreakerConfig{MinRequests: 2, Window: time.Minute})
+	b.now = func() time.Time { return now }
+
+	// the failures of an expired window are not counted
+	adm, _ := b.allow()
+	b.record(adm, true)
+	now = now.Add(time.Minute)
+	adm, _ = b.allow()
+	b.record(adm, true)
+	if got := b.currentState(now); got != CircuitClosed {
+		t.Fatalf("got state '%v'; want 'closed'", got)
+	}
+	adm, _ = b.allow()
+	b.record(adm, true)
+	if got := b.currentState(now); got != CircuitOpen {
+		t.Fatalf("got state '%v'; want 'open'", got)
+	}
+}
+
+func TestBreakerAbandon(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBreaker(CircuitBreakerConfig{MinRequests: 1, OpenTimeout: time.Second})
+	b.now = func() time.Time { return now }
+	adm, _ := b.allow()
+	b.record(adm, true)
+
+	// an abandoned probe frees its slot
+	now = now.Add(time.Second)
+	adm, ok := b.allow()
+	if !ok {
+		t.Fatalf("got probe refused; want it allowed")
+	}
+	b.abandon(adm)
+	if _, ok := b.allow(); !ok {
+		t.Fatalf("got probe refused after abandoning the previous one; want it allowed")
+	}
+}
+
+func TestBreakerStaleOutcome(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBreaker(CircuitBreakerConfig{MinRequests: 1, OpenTimeout: time.Second})
+	b.now = func() time.Time { return now }
+
+	// a slow call is allowed while closed, then another call trips the breaker
+	slow, _ := b.allow()
+	adm, _ := b.allow()
+	b.record(adm, true)
+
+	// the slow call ends while the probe is in flight
+	now = now.Add(time.Second)
+	probe, ok := b.allow()
+	if !ok {
+		t.Fatalf("got probe refused; want it allowed")
+	}
+	b.record(slow, false)
+	b.abandon(slow)
+
+	// the slow call must not free the probe's slot, nor close the breaker
+	if _, ok := b.allow(); ok {
+		t.Errorf("got call allowed; want it refused while the probe is in flight")
+	}
+	if got := b.currentState(now); got != CircuitHalfOpen {
+		t.Errorf("got state '%v'; want 'half-open'", got)
+	}
+
+	// the probe's own outcome still closes the breaker
+	b.record(probe, false)
+	if got := b.currentState(now); got != CircuitClosed {
+		t.Errorf("got state '%v'; want 'closed'", got)
+	}
+}
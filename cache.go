@@ -0,0 +1,93 @@
+package fatsecret
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/fitzone/fatsecret/oauth1"
+)
+
+// CacheEntry is a cached API response body
+type CacheEntry struct {
+	Body   []byte    // the response body
+	Stored time.Time // when the response was received
+}
+
+// Cache stores API response bodies by request. Entries are kept past the
+// cache ttl so that stale data can be served while the circuit breaker
+// is open. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+}
+
+// WithCache caches the successful API responses in the given cache. A
+// cached response is served for ttl after it was received.
+func WithCache(cache Cache, ttl time.Duration) Option {
+	return func(c *Client) {
+		c.cache = cache
+		c.cacheTTL = ttl
+	}
+}
+
+// MemoryCache is an in-memory Cache holding a limited number of entries
+type MemoryCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]CacheEntry
+}
+
+// NewMemoryCache creates an in-memory cache. When it holds maxEntries,
+// the oldest entry is evicted to make room; zero means no limit.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    map[string]CacheEntry{},
+	}
+}
+
+// Get returns the cached entry of the key
+func (m *MemoryCache) Get(key string) (CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	return entry, ok
+}
+
+// Set caches the entry of the key, evicting the oldest entry when full
+func (m *MemoryCache) Set(key string, entry CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[key]; !ok && m.maxEntries > 0 && len(m.entries) >= m.maxEntries {
+		oldest := ""
+		for k, e := range m.entries {
+			if oldest == "" || e.Stored.Before(m.entries[oldest].Stored) {
+				oldest = k
+			}
+		}
+		delete(m.entries, oldest)
+	}
+	m.entries[key] = entry
+}
+
+// Len returns the number of cached entries
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// cacheKey builds the cache key of an API call from its method, response
+// format and parameters, encoded and sorted like the request query so
+// that values containing '&' or '=' cannot collide
+func cacheKey(apiMethod string, format Format, params map[string]string) string {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	values.Set("method", apiMethod)
+	values.Set("format", format.String())
+	return oauth1.EncodeQuery(values)
+}
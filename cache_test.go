@@ -0,0 +1,139 @@
+package fatsecret_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/fatsecrettest"
+)
+
+func TestCache(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+
+	cache := fatsecret.NewMemoryCache(10)
+	client, err := srv.NewClient(fatsecret.WithCache(cache, time.Hour))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// the second call is served from the cache
+	for i := 0; i < 2; i++ {
+		food, err := client.FoodByID("1")
+		if err != nil {
+			t.Fatalf("Could not fetch food: '%v'", err)
+		}
+		if food.Name != "Apple" {
+			t.Errorf("got food '%s'; want 'Apple'", food.Name)
+		}
+	}
+	if got := len(srv.Requests()); got != 1 {
+		t.Errorf("got %d requests; want 1", got)
+	}
+
+	// error responses are not cached
+	client.FoodByID("2")
+	if got := cache.Len(); got != 1 {
+		t.Errorf("got %d cached entries; want 1", got)
+	}
+}
+
+func TestCacheKeys(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+	srv.AddFood(fatsecret.FoodInfo{ID: "1&format=xml", Name: "Pear"})
+
+	// the clients share the cache, but request other formats
+	cache := fatsecret.NewMemoryCache(10)
+	jsonClient, err := srv.NewClient(fatsecret.WithCache(cache, time.Hour))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	xmlClient, err := srv.NewClient(fatsecret.WithCache(cache, time.Hour), fatsecret.WithFormat(fatsecret.FormatXML))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// each format and each parameter value is cached separately
+	for _, client := range []*fatsecret.Client{jsonClient, xmlClient} {
+		for id, name := range map[string]string{"1": "Apple", "1&format=xml": "Pear"} {
+			food, err := client.FoodByID(id)
+			if err != nil {
+				t.Fatalf("Could not fetch food '%s': '%v'", id, err)
+			}
+			if food.Name != name {
+				t.Errorf("got food '%s'; want '%s'", food.Name, name)
+			}
+		}
+	}
+	if got := cache.Len(); got != 4 {
+		t.Errorf("got %d cached entries; want 4", got)
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	cache := fatsecret.NewMemoryCache(2)
+	start := time.Now()
+	cache.Set("a", fatsecret.CacheEntry{Body: []byte("a"), Stored: start})
+	cache.Set("b", fatsecret.CacheEntry{Body: []byte("b"), Stored: start.Add(time.Second)})
+	cache.Set("c", fatsecret.CacheEntry{Body: []byte("c"), Stored: start.Add(2 * time.Second)})
+
+	// the oldest entry is evicted
+	if _, ok := cache.Get("a"); ok {
+		t.Errorf("got entry 'a'; want it evicted")
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("got no entry '%s'; want it cached", key)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+
+	// cache the food with a ttl which expires immediately
+	client, err := srv.NewClient(
+		fatsecret.WithCache(fatsecret.NewMemoryCache(0), 0),
+		fatsecret.WithCircuitBreaker(fatsecret.CircuitBreakerConfig{MinRequests: 2, OpenTimeout: time.Hour}),
+	)
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if _, err := client.FoodByID("1"); err != nil {
+		t.Fatalf("Could not fetch food: '%v'", err)
+	}
+
+	// an outage trips the breaker
+	srv.InjectFault("", fatsecrettest.Fault{Status: http.StatusServiceUnavailable})
+	client.FoodByID("1")
+	client.FoodByID("1")
+	if got := client.CircuitState(); got != fatsecret.CircuitOpen {
+		t.Fatalf("got circuit state '%v'; want 'open'", got)
+	}
+	requests := len(srv.Requests())
+
+	// the stale food is served while the circuit is open
+	food, err := client.FoodByID("1")
+	if err != nil {
+		t.Fatalf("got error '%v'; want the stale food", err)
+	}
+	if food.Name != "Apple" {
+		t.Errorf("got food '%s'; want 'Apple'", food.Name)
+	}
+
+	// calls without a cached response fail fast
+	if _, err := client.FoodByID("2"); !errors.Is(err, fatsecret.ErrCircuitOpen) {
+		t.Errorf("got error '%v'; want '%v'", err, fatsecret.ErrCircuitOpen)
+	}
+	if got := len(srv.Requests()); got != requests {
+		t.Errorf("got %d requests while open; want %d", got, requests)
+	}
+}
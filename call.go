@@ -0,0 +1,102 @@
+package fatsecret
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Response holds the http metadata and body of an API call
+type Response struct {
+	StatusCode int           // the http status code, or zero if no response was received
+	Header     http.Header   // the http response headers
+	Body       []byte        // the response body
+	Latency    time.Duration // the latency of the last http attempt
+	URL        string        // the request URL, with the oauth credentials and signature redacted
+	Attempts   int           // the number of http attempts, zero when served from the cache
+	Cached     bool          // whether the response was served from the cache
+}
+
+// CallOption configures a single call of a typed API method. The options
+// are passed to the method in its context, using WithCallOptions, so they
+// only apply to the methods which take a context.
+type CallOption func(*callOptions)
+
+// callOptions are the resolved options of a call
+type callOptions struct {
+	response   *Response
+	responseMu *sync.Mutex
+	locale     *Locale
+}
+
+// callOptionsKey is the context key of the call options
+type callOptionsKey struct{}
+
+// WithCallOptions returns a copy of the context which carries the call
+// options, after any the context already carries, to the typed API
+// methods invoked with it (ie: client.FoodByIDV4(ctx, "1")).
+//
+// The options apply to every API call made with the context or a context
+// derived from it, including the nested calls of a method (ie: those of
+// CategoryTree), so derive a new context for each call which needs its
+// own options. The methods without a context (ie: FoodByID) cannot be
+// given options.
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	prev, _ := ctx.Value(callOptionsKey{}).([]CallOption)
+	all := append(append([]CallOption{}, prev...), opts...)
+	return context.WithValue(ctx, callOptionsKey{}, all)
+}
+
+// WithResponse stores the http metadata and body of the call in resp.
+// For methods which make several API calls (ie: FoodByBarcode) it holds
+// the last API call to complete.
+func WithResponse(resp *Response) CallOption {
+	// the API calls of a method may complete concurrently (ie: those of
+	// a category tree)
+	mu := &sync.Mutex{}
+	return func(o *callOptions) {
+		o.response = resp
+		o.responseMu = mu
+	}
+}
+
+// newCallOptions resolves the call options carried by the context,
+// followed by the given options
+func newCallOptions(ctx context.Context, opts []CallOption) callOptions {
+	o := callOptions{}
+	if ctxOpts, ok := ctx.Value(callOptionsKey{}).([]CallOption); ok {
+		for _, opt := range ctxOpts {
+			opt(&o)
+		}
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// setResponse stores the metadata of a completed API call in the
+// response requested by the caller, if any
+func (o callOptions) setResponse(meta *Response) {
+	if o.response == nil {
+		return
+	}
+	o.responseMu.Lock()
+	*o.response = *meta
+	o.responseMu.Unlock()
+}
+
+// InvokeAPIRaw is like InvokeAPIContext, but also returns the http status,
+// headers, latency and redacted request URL of the call. The response is
+// returned along with any error, such as an UnexpectedResponseError.
+func (c *Client) InvokeAPIRaw(ctx context.Context, apiMethod string, params map[string]string) (*Response, error) {
+	resp := &Response{}
+	err := c.invoke(ctx, apiMethod, params, func(r io.Reader) error {
+		_, err := io.Copy(ioutil.Discard, r)
+		return err
+	}, WithResponse(resp))
+	return resp, err
+}
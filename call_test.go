@@ -0,0 +1,190 @@
+package fatsecret_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/fatsecrettest"
+)
+
+func TestInvokeAPIRaw(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+
+	// fetch the raw response
+	resp, err := client.InvokeAPIRaw(context.Background(), "food.get", map[string]string{"food_id": "1"})
+	if err != nil {
+		t.Fatalf("Could not invoke api: '%v'", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d; want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("got content type '%s'; want 'application/json'", got)
+	}
+	if !strings.Contains(string(resp.Body), `"food_name":"Apple"`) {
+		t.Errorf("got body '%s'; want the food", resp.Body)
+	}
+	if resp.Latency <= 0 || resp.Attempts != 1 || resp.Cached {
+		t.Errorf("got latency %v, %d attempts and cached %v; want one uncached attempt", resp.Latency, resp.Attempts, resp.Cached)
+	}
+
+	// the credentials and signature are redacted from the url
+	if !strings.Contains(resp.URL, "food_id=1") {
+		t.Errorf("got url '%s'; want the food id", resp.URL)
+	}
+	for _, secret := range []string{"oauth_signature=", "oauth_consumer_key="} {
+		if !strings.Contains(resp.URL, secret+"REDACTED") {
+			t.Errorf("got url '%s'; want '%s' redacted", resp.URL, secret)
+		}
+	}
+	if strings.Contains(resp.URL, "test-key") {
+		t.Errorf("got url '%s'; want no consumer key", resp.URL)
+	}
+}
+
+func TestInvokeAPIRawError(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	// the response is returned along with the error
+	srv.InjectFault("food.get", fatsecrettest.Fault{Status: http.StatusTooManyRequests})
+	resp, err := client.InvokeAPIRaw(context.Background(), "food.get", map[string]string{"food_id": "1"})
+	unexpected := &fatsecret.UnexpectedResponseError{}
+	if !errors.As(err, &unexpected) {
+		t.Errorf("got error '%v'; want an unexpected response error", err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("got status %d; want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+}
+
+func TestInvokeAPIRawErrorBody(t *testing.T) {
+	// an api behind a proxy which returns an html error page
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, "<html>Bad Gateway</html>")
+	}))
+	defer api.Close()
+	client, err := fatsecret.NewClient("test-key", "test-secret", fatsecret.WithAPIURL(api.URL))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// the body of the failed call is returned with the error
+	resp, err := client.InvokeAPIRaw(context.Background(), "food.get", map[string]string{"food_id": "1"})
+	unexpected := &fatsecret.UnexpectedResponseError{}
+	if !errors.As(err, &unexpected) {
+		t.Fatalf("got error '%v'; want an unexpected response error", err)
+	}
+	if string(resp.Body) != "<html>Bad Gateway</html>" || string(unexpected.Body) != string(resp.Body) {
+		t.Errorf("got bodies '%s' and '%s'; want the error page", resp.Body, unexpected.Body)
+	}
+
+	// the body is also given to the typed methods' response
+	typed := fatsecret.Response{}
+	if _, err := client.FoodByIDV4(fatsecret.WithCallOptions(context.Background(), fatsecret.WithResponse(&typed)), "1"); err == nil {
+		t.Fatalf("got no error; want an unexpected response error")
+	}
+	if typed.StatusCode != http.StatusBadGateway || string(typed.Body) != "<html>Bad Gateway</html>" {
+		t.Errorf("got response %+v; want the error page", typed)
+	}
+}
+
+func TestWithResponse(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+	client, err := srv.NewClient(fatsecret.WithCache(fatsecret.NewMemoryCache(0), time.Hour))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// the typed method hands back the response metadata
+	resp := fatsecret.Response{}
+	food, err := client.FoodByIDV4(fatsecret.WithCallOptions(context.Background(), fatsecret.WithResponse(&resp)), "1")
+	if err != nil {
+		t.Fatalf("Could not fetch food: '%v'", err)
+	}
+	if food.Name != "Apple" {
+		t.Errorf("got food '%s'; want 'Apple'", food.Name)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Attempts != 1 || len(resp.Body) == 0 {
+		t.Errorf("got response %+v; want the http response", resp)
+	}
+
+	// a cached response is flagged
+	cached := fatsecret.Response{}
+	if _, err := client.FoodByIDV4(fatsecret.WithCallOptions(context.Background(), fatsecret.WithResponse(&cached)), "1"); err != nil {
+		t.Fatalf("Could not fetch food: '%v'", err)
+	}
+	if !cached.Cached || cached.Attempts != 0 || string(cached.Body) != string(resp.Body) {
+		t.Errorf("got response %+v; want the cached response", cached)
+	}
+}
+
+func TestWithResponseConcurrent(t *testing.T) {
+	client, srv := newTestClient(t)
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		srv.AddCategory(fatsecret.FoodCategory{ID: id, Name: "Category " + id}, "Sub "+id)
+	}
+
+	// the sub-categories are fetched concurrently into the same response
+	resp := fatsecret.Response{}
+	if _, err := client.CategoryTree(fatsecret.WithCallOptions(context.Background(), fatsecret.WithResponse(&resp))); err != nil {
+		t.Fatalf("Could not fetch category tree: '%v'", err)
+	}
+	if !strings.Contains(resp.URL, "method=food_sub_categories.get") {
+		t.Errorf("got url '%s'; want the last sub-categories call", resp.URL)
+	}
+}
+
+func TestLocale(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Pomme"})
+
+	// an unsupported client locale is rejected
+	if _, err := srv.NewClient(fatsecret.WithLocale(fatsecret.Locale{Region: "XX"})); err == nil {
+		t.Errorf("got no error; want an invalid region error")
+	}
+
+	// the client locale is sent, unless overridden per call
+	client, err := srv.NewClient(fatsecret.WithLocale(fatsecret.Locale{
+		Region:   fatsecret.RegionFrance,
+		Language: fatsecret.LanguageFrench,
+	}))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if _, err := client.FoodByID("1"); err != nil {
+		t.Fatalf("Could not fetch food: '%v'", err)
+	}
+	ctx := fatsecret.WithCallOptions(context.Background(), fatsecret.ForLocale(fatsecret.Locale{Region: fatsecret.RegionCanada}))
+	if _, err := client.FoodByIDV4(ctx, "1"); err != nil {
+		t.Fatalf("Could not fetch food: '%v'", err)
+	}
+	requests := srv.Requests()
+	if got := requests[0].Params.Get("region") + "/" + requests[0].Params.Get("language"); got != "FR/fr" {
+		t.Errorf("got locale '%s'; want 'FR/fr'", got)
+	}
+	if got := requests[1].Params.Get("region") + "/" + requests[1].Params.Get("language"); got != "CA/" {
+		t.Errorf("got locale '%s'; want 'CA/'", got)
+	}
+
+	// an unsupported call locale fails without a request
+	ctx = fatsecret.WithCallOptions(context.Background(), fatsecret.ForLocale(fatsecret.Locale{Region: "XX"}))
+	if _, err := client.FoodByIDV4(ctx, "1"); err == nil {
+		t.Errorf("got no error; want an invalid region error")
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("got %d requests; want 2", got)
+	}
+}
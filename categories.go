@@ -1,18 +1,53 @@
 package fatsecret
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"strings"
+	"sync"
+	"time"
 )
 
+const (
+	// the default maximum concurrent api calls when building the category tree
+	categoryTreeConcurrency = 4
+
+	// the default time for which the sub-categories are cached
+	categoryCacheTTL = 24 * time.Hour
+)
+
+// WithCategoryConcurrency sets the maximum number of sub-category calls
+// which CategoryTree makes at once (4 by default)
+func WithCategoryConcurrency(n int) Option {
+	return func(c *Client) {
+		c.categoryConcurrency = n
+	}
+}
+
+// WithCategoryCacheTTL sets how long CategoryTree caches the
+// sub-categories of each category (24 hours by default)
+func WithCategoryCacheTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.categoryTTL = ttl
+	}
+}
+
+// subCategoryEntry holds the cached sub-categories of a category
+type subCategoryEntry struct {
+	subs   []string
+	stored time.Time
+}
+
 type FoodCategory struct {
-	ID          string `json:"food_category_id"`
-	Name        string `json:"food_category_name"`
-	Description string `json:"food_category_description"`
+	ID          string `json:"food_category_id" xml:"food_category_id"`
+	Name        string `json:"food_category_name" xml:"food_category_name"`
+	Description string `json:"food_category_description" xml:"food_category_description"`
 }
 
 type FoodCategories struct {
-	Categories []FoodCategory `json:"food_category"`
+	Categories []FoodCategory `json:"food_category" xml:"food_category"`
 }
 
 type FoodCategoriesResponse struct {
@@ -20,8 +55,36 @@ type FoodCategoriesResponse struct {
 	Error      *ErrorResponse  `json:"error,omitempty"`
 }
 
+// UnmarshalJSON handles the API returning a single category, rather
+// than an array, when there is only one
+func (f *FoodCategories) UnmarshalJSON(data []byte) error {
+	raw := struct {
+		Categories json.RawMessage `json:"food_category"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	f.Categories = nil
+	return unmarshalJSONArray(raw.Categories, &f.Categories)
+}
+
+// UnmarshalXML decodes either a '<food_categories>' or an '<error>' response
+func (r *FoodCategoriesResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if isXMLError(start) {
+		r.Error = &ErrorResponse{}
+		return d.DecodeElement(r.Error, &start)
+	}
+	r.Categories = &FoodCategories{}
+	return d.DecodeElement(r.Categories, &start)
+}
+
+// MarshalXML encodes either a '<food_categories>' or an '<error>' response
+func (r FoodCategoriesResponse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalXMLResponse(e, r.Error, "food_categories", r.Categories)
+}
+
 type FoodSubCategories struct {
-	SubCategories []string `json:"food_sub_category"`
+	SubCategories []string `json:"food_sub_category" xml:"food_sub_category"`
 }
 
 type FoodSubCategoriesResponse struct {
@@ -29,21 +92,55 @@ type FoodSubCategoriesResponse struct {
 	Error         *ErrorResponse     `json:"error,omitempty"`
 }
 
+// UnmarshalJSON handles the API returning a single sub-category, rather
+// than an array, when there is only one
+func (f *FoodSubCategories) UnmarshalJSON(data []byte) error {
+	raw := struct {
+		SubCategories json.RawMessage `json:"food_sub_category"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	f.SubCategories = nil
+	return unmarshalJSONArray(raw.SubCategories, &f.SubCategories)
+}
+
+// UnmarshalXML decodes either a '<food_sub_categories>' or an '<error>' response
+func (r *FoodSubCategoriesResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if isXMLError(start) {
+		r.Error = &ErrorResponse{}
+		return d.DecodeElement(r.Error, &start)
+	}
+	r.SubCategories = &FoodSubCategories{}
+	return d.DecodeElement(r.SubCategories, &start)
+}
+
+// MarshalXML encodes either a '<food_sub_categories>' or an '<error>' response
+func (r FoodSubCategoriesResponse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalXMLResponse(e, r.Error, "food_sub_categories", r.SubCategories)
+}
+
 // FoodCategories invokes the FatSecret 'food_categories.get' API call
 // and returns the response as a slice of FoodCategory structs
 func (c *Client) FoodCategories() ([]FoodCategory, error) {
-	// invoke the api call
-	body, err := c.InvokeAPI(
-		"food_categories.get",
-		map[string]string{},
-	)
-	if err != nil {
-		return nil, err
-	}
+	return c.foodCategories(context.Background())
+}
+
+// foodCategories invokes the 'food_categories.get' API call bound
+// to the given context
+func (c *Client) foodCategories(ctx context.Context) (categories []FoodCategory, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "FoodCategories")
+	defer func() { c.endSpan(span, err) }()
 
-	// parse the api response
+	// invoke the api call, decoding the response
 	resp := FoodCategoriesResponse{}
-	if err := json.Unmarshal(body, &resp); err != nil {
+	if err := c.invokeDecode(
+		ctx,
+		"food_categories.get",
+		map[string]string{},
+		&resp,
+	); err != nil {
 		return nil, err
 	}
 
@@ -53,6 +150,11 @@ func (c *Client) FoodCategories() ([]FoodCategory, error) {
 		return nil, errors.New(resp.Error.Message)
 	}
 
+	// if no categories were returned
+	if resp.Categories == nil {
+		return nil, nil
+	}
+
 	// return the slice of food category entries
 	return resp.Categories.Categories, nil
 }
@@ -60,20 +162,26 @@ func (c *Client) FoodCategories() ([]FoodCategory, error) {
 // FoodSubCategories invokes the FatSecret 'food_sub_categories.get'
 // API call and returns a slice of sub-categories for a given category
 func (c *Client) FoodSubCategories(id string) ([]string, error) {
-	// invoke the api call
-	body, err := c.InvokeAPI(
+	return c.foodSubCategories(context.Background(), id)
+}
+
+// foodSubCategories invokes the 'food_sub_categories.get' API call bound
+// to the given context
+func (c *Client) foodSubCategories(ctx context.Context, id string) (subs []string, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "FoodSubCategories", StringAttribute(AttrCategoryID, id))
+	defer func() { c.endSpan(span, err) }()
+
+	// invoke the api call, decoding the response
+	resp := FoodSubCategoriesResponse{}
+	if err := c.invokeDecode(
+		ctx,
 		"food_sub_categories.get",
 		map[string]string{
 			"food_category_id": id,
 		},
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	// parse the api response
-	resp := FoodSubCategoriesResponse{}
-	if err := json.Unmarshal(body, &resp); err != nil {
+		&resp,
+	); err != nil {
 		return nil, err
 	}
 
@@ -83,6 +191,226 @@ func (c *Client) FoodSubCategories(id string) ([]string, error) {
 		return nil, errors.New(resp.Error.Message)
 	}
 
+	// if no sub-categories were returned
+	if resp.SubCategories == nil {
+		return nil, nil
+	}
+
 	// return the slice of food sub-categories
 	return resp.SubCategories.SubCategories, nil
 }
+
+// CategoryNode is a food category along with its sub-categories
+type CategoryNode struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"`
+	SubCategories []string `json:"sub_categories"`
+}
+
+// CategoryTree is the navigable tree of all food categories and their
+// sub-categories. It can be serialized to JSON and loaded back as a snapshot.
+type CategoryTree struct {
+	Categories []*CategoryNode `json:"categories"`
+
+	// lookup indexes, rebuilt after decoding
+	byID          map[string]*CategoryNode
+	byName        map[string]*CategoryNode
+	bySubCategory map[string]*CategoryNode
+}
+
+// CategoryTree fetches all of the food categories and their sub-categories
+// and returns them as a tree. The sub-categories are fetched concurrently,
+// up to WithCategoryConcurrency at a time, and cached on the client for
+// later trees. When the client has a WithRateLimit interval, they are
+// fetched one at a time, spaced by the rate limiter.
+func (c *Client) CategoryTree(ctx context.Context) (tree *CategoryTree, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "CategoryTree")
+	defer func() { c.endSpan(span, err) }()
+
+	// fetch the top-level categories
+	categories, err := c.foodCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// cancel the remaining calls after the first error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// fetch the sub-categories of each category
+	nodes := make([]*CategoryNode, len(categories))
+	var firstErr error
+	errOnce := sync.Once{}
+	sem := make(chan struct{}, c.categoryTreeConcurrency())
+	wg := sync.WaitGroup{}
+	for i, cat := range categories {
+		nodes[i] = &CategoryNode{
+			ID:          cat.ID,
+			Name:        cat.Name,
+			Description: cat.Description,
+		}
+
+		wg.Add(1)
+		go func(node *CategoryNode) {
+			defer wg.Done()
+
+			// limit the number of concurrent api calls
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			// skip the call if another one already failed
+			if ctx.Err() != nil {
+				return
+			}
+
+			subs, err := c.cachedSubCategories(ctx, node.ID)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			node.SubCategories = subs
+		}(nodes[i])
+	}
+	wg.Wait()
+
+	// return the first error, if any
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewCategoryTree(nodes), nil
+}
+
+// categoryTreeConcurrency returns the maximum concurrent sub-category
+// calls, which is one when rate limited, since concurrent calls would
+// only queue on the rate limiter
+func (c *Client) categoryTreeConcurrency() int {
+	switch {
+	case c.rateInterval > 0:
+		return 1
+	case c.categoryConcurrency > 0:
+		return c.categoryConcurrency
+	}
+	return categoryTreeConcurrency
+}
+
+// NewCategoryTree creates a category tree from the given categories
+func NewCategoryTree(categories []*CategoryNode) *CategoryTree {
+	tree := &CategoryTree{Categories: categories}
+	tree.index()
+	return tree
+}
+
+// ResetCategoryCache discards the cached sub-categories so that the
+// next category tree fetches them again
+func (c *Client) ResetCategoryCache() {
+	c.categoryMu.Lock()
+	c.subCategories = nil
+	c.categoryMu.Unlock()
+}
+
+// cachedSubCategories returns the cached sub-categories of the category,
+// fetching and caching them when needed or when they have expired. They
+// are cached by the locale of the call, as well as the category id.
+func (c *Client) cachedSubCategories(ctx context.Context, id string) ([]string, error) {
+	key := c.callLocale(newCallOptions(ctx, nil)).String() + "/" + id
+
+	// check the cache
+	c.categoryMu.Lock()
+	entry, ok := c.subCategories[key]
+	c.categoryMu.Unlock()
+	if ok && time.Since(entry.stored) < c.categoryCacheTTL() {
+		return entry.subs, nil
+	}
+
+	// fetch the sub-categories
+	subs, err := c.foodSubCategories(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// cache the sub-categories
+	c.categoryMu.Lock()
+	if c.subCategories == nil {
+		c.subCategories = map[string]subCategoryEntry{}
+	}
+	c.subCategories[key] = subCategoryEntry{subs: subs, stored: time.Now()}
+	c.categoryMu.Unlock()
+
+	return subs, nil
+}
+
+// categoryCacheTTL returns how long the sub-categories are cached
+func (c *Client) categoryCacheTTL() time.Duration {
+	if c.categoryTTL > 0 {
+		return c.categoryTTL
+	}
+	return categoryCacheTTL
+}
+
+// ByID returns the category with the given id
+func (t *CategoryTree) ByID(id string) (*CategoryNode, bool) {
+	node, ok := t.byID[id]
+	return node, ok
+}
+
+// ByName returns the category with the given name (not case sensitive)
+func (t *CategoryTree) ByName(name string) (*CategoryNode, bool) {
+	node, ok := t.byName[strings.ToLower(name)]
+	return node, ok
+}
+
+// BySubCategory returns the category which contains the given
+// sub-category name (not case sensitive)
+func (t *CategoryTree) BySubCategory(name string) (*CategoryNode, bool) {
+	node, ok := t.bySubCategory[strings.ToLower(name)]
+	return node, ok
+}
+
+// UnmarshalJSON decodes a category tree snapshot and rebuilds its indexes
+func (t *CategoryTree) UnmarshalJSON(data []byte) error {
+	// decode using an alias type to avoid recursion
+	type tree CategoryTree
+	decoded := tree{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*t = CategoryTree(decoded)
+
+	// drop the null categories, which have nothing to index
+	categories := t.Categories[:0]
+	for _, node := range t.Categories {
+		if node != nil {
+			categories = append(categories, node)
+		}
+	}
+	t.Categories = categories
+
+	t.index()
+	return nil
+}
+
+// index builds the lookup indexes of the tree
+func (t *CategoryTree) index() {
+	t.byID = map[string]*CategoryNode{}
+	t.byName = map[string]*CategoryNode{}
+	t.bySubCategory = map[string]*CategoryNode{}
+	for _, node := range t.Categories {
+		if node == nil {
+			continue
+		}
+		t.byID[node.ID] = node
+		t.byName[strings.ToLower(node.Name)] = node
+		for _, sub := range node.SubCategories {
+			t.bySubCategory[strings.ToLower(sub)] = node
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package fatsecret
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCategoryTreeJSON(t *testing.T) {
+	tree := NewCategoryTree([]*CategoryNode{
+		{ID: "16", Name: "Desserts", SubCategories: []string{"Cake", "Ice Cream"}},
+		{ID: "2", Name: "Beverages", SubCategories: []string{"Coffee"}},
+	})
+
+	// round-trip the tree through json
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("Could not encode tree: '%v'", err)
+	}
+	decoded := &CategoryTree{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Could not decode tree: '%v'", err)
+	}
+
+	// the lookups must work on the decoded snapshot
+	if node, ok := decoded.ByID("16"); !ok || node.Name != "Desserts" {
+		t.Errorf("got %+v; want the 'Desserts' category", node)
+	}
+	if node, ok := decoded.ByName("beverages"); !ok || node.ID != "2" {
+		t.Errorf("got %+v; want the 'Beverages' category", node)
+	}
+	if node, ok := decoded.BySubCategory("ice cream"); !ok || node.ID != "16" {
+		t.Errorf("got %+v; want the 'Desserts' category", node)
+	}
+	if _, ok := decoded.ByID("42"); ok {
+		t.Errorf("got a category for an unknown id")
+	}
+}
+
+func TestCategoryTreeJSONNullCategory(t *testing.T) {
+	// a null category must be skipped, rather than panic
+	decoded := &CategoryTree{}
+	if err := json.Unmarshal([]byte(`{"categories":[null,{"id":"2","name":"Beverages"}]}`), decoded); err != nil {
+		t.Fatalf("Could not decode tree: '%v'", err)
+	}
+	if len(decoded.Categories) != 1 {
+		t.Errorf("got %d categories; want 1", len(decoded.Categories))
+	}
+	if node, ok := decoded.ByID("2"); !ok || node.Name != "Beverages" {
+		t.Errorf("got %+v; want the 'Beverages' category", node)
+	}
+
+	// a tree built with a nil category must also index
+	tree := NewCategoryTree([]*CategoryNode{nil})
+	if _, ok := tree.ByID(""); ok {
+		t.Errorf("got a category for the nil node")
+	}
+}
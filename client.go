@@ -1,15 +1,20 @@
 package fatsecret
 
 import (
+	"bytes"
+	"context"
 	"errors"
-	"fmt"
+	"io"
 	"io/ioutil"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	"net/url"
-	"sort"
-	"strings"
+	"strconv"
+	"sync"
 	"time"
+
+	"github.com/fitzone/fatsecret/oauth1"
 )
 
 const (
@@ -19,16 +24,76 @@ const (
 // Client is the top-level FatSecret client which is used to
 // fetch data from the FatSecret API using Oauth1 authentication
 type Client struct {
-	consumerKey    string
-	consumerSecret string
-	apiURL         string
-	escapedAPIURL  string
-	randSrc        rand.Source
-	signer         Signer
+	consumerKey     string
+	consumerSecret  string
+	apiURL          string
+	randSrc         rand.Source
+	randMu          sync.Mutex
+	signer          Signer
+	httpClient      *http.Client
+	format          Format
+	locale          Locale
+	maxResponseSize int64
+
+	// logging and hooks
+	logger       *slog.Logger
+	debug        bool
+	requestHook  func(RequestInfo)
+	responseHook func(ResponseInfo)
+	metrics      Metrics
+	tracer       Tracer
+
+	// retries and rate limiting
+	maxRetries   int
+	retryBackoff time.Duration
+	rateInterval time.Duration
+	rateMu       sync.Mutex
+	rateNext     time.Time
+
+	// response cache and circuit breaker
+	cache    Cache
+	cacheTTL time.Duration
+	breaker  *breaker
+
+	// cache of sub-categories by locale and category id
+	categoryMu          sync.Mutex
+	categoryConcurrency int
+	categoryTTL         time.Duration
+	subCategories       map[string]subCategoryEntry
+}
+
+// Option configures an optional setting of the Client
+type Option func(*Client)
+
+// WithAPIURL sets the FatSecret API endpoint, such as the URL of a
+// local test server
+func WithAPIURL(apiURL string) Option {
+	return func(c *Client) {
+		c.apiURL = apiURL
+	}
+}
+
+// WithHTTPClient sets the http client used to invoke the API, such as
+// one with a timeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTransport sets the http transport used to invoke the API, such as
+// a recording or replaying test transport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		// keep the other settings of the http client (ie: its timeout)
+		httpClient := *c.httpClient
+		httpClient.Transport = transport
+		c.httpClient = &httpClient
+	}
 }
 
 // NewClient creates and returns a new FatSecret client instance
-func NewClient(consumerKey string, consumerSecret string) (*Client, error) {
+func NewClient(consumerKey string, consumerSecret string, opts ...Option) (*Client, error) {
 	// validate the given key and secret
 	if consumerKey == "" {
 		return nil, errors.New("Invalid consumer key given")
@@ -37,120 +102,318 @@ func NewClient(consumerKey string, consumerSecret string) (*Client, error) {
 		return nil, errors.New("Invalid consumer key given")
 	}
 
+	// create the new client
+	c := &Client{
+		consumerKey:     consumerKey,
+		consumerSecret:  consumerSecret,
+		apiURL:          fatSecretAPIURL,
+		randSrc:         rand.NewSource(time.Now().UnixNano()),
+		signer:          NewHMACSigner(consumerSecret),
+		httpClient:      http.DefaultClient,
+		maxResponseSize: DefaultMaxResponseSize,
+	}
+
+	// apply the optional settings
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.locale.Validate(); err != nil {
+		return nil, err
+	}
+
 	// return the new client
-	return &Client{
-		consumerKey:    consumerKey,
-		consumerSecret: consumerSecret,
-		apiURL:         fatSecretAPIURL,
-		escapedAPIURL:  url.QueryEscape(fatSecretAPIURL),
-		randSrc:        rand.NewSource(time.Now().UnixNano()),
-		signer:         NewHMACSigner(consumerSecret),
-	}, nil
+	return c, nil
 }
 
 // InvokeAPI calls the FatSecret API and returns the response body.
 // This lower-level function is used by all higher-level API functions (ie: FoodSearch)
 func (c *Client) InvokeAPI(apiMethod string, params map[string]string) ([]byte, error) {
-	// build the oauth api url
-	apiURL, err := c.buildURL(apiMethod, params)
-	if err != nil {
+	return c.InvokeAPIContext(context.Background(), apiMethod, params)
+}
+
+// InvokeAPIContext is like InvokeAPI, but the http request is bound to
+// the given context so it can be cancelled or given a deadline
+func (c *Client) InvokeAPIContext(ctx context.Context, apiMethod string, params map[string]string) ([]byte, error) {
+	var body []byte
+	if err := c.invoke(ctx, apiMethod, params, readAll(&body)); err != nil {
 		return nil, err
 	}
+	return body, nil
+}
 
-	// invoke the http api call
-	resp, err := http.Get(apiURL)
+// invokeDecode invokes the API call and decodes the response into v,
+// streaming it straight from the response body
+func (c *Client) invokeDecode(ctx context.Context, apiMethod string, params map[string]string, v interface{}) error {
+	return c.invoke(ctx, apiMethod, params, func(r io.Reader) error {
+		return c.decodeFrom(r, v)
+	})
+}
+
+// invoke invokes the API call and passes the response body to read,
+// serving it from the cache when possible
+func (c *Client) invoke(ctx context.Context, apiMethod string, params map[string]string, read func(io.Reader) error, opts ...CallOption) error {
+	o := newCallOptions(ctx, opts)
+	meta := &Response{}
+	defer o.setResponse(meta)
+
+	// add the locale to the parameters
+	params, err := c.localize(apiMethod, params, o)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// read the response message body
-	body, err := ioutil.ReadAll(resp.Body)
-	resp.Body.Close()
-	if err != nil {
-		return nil, err
+	// serve a fresh cached response
+	var key string
+	var cached CacheEntry
+	var hasCached bool
+	if c.cache != nil {
+		key = cacheKey(apiMethod, c.format, params)
+		cached, hasCached = c.cache.Get(key)
+		fresh := hasCached && time.Since(cached.Stored) < c.cacheTTL
+		if c.metrics != nil {
+			c.metrics.ObserveCache(apiMethod, fresh)
+		}
+		if fresh {
+			meta.Body, meta.Cached = cached.Body, true
+			return read(bytes.NewReader(cached.Body))
+		}
 	}
 
-	// return the response message body
-	return body, nil
+	// fail fast while the circuit breaker is open, serving any stale
+	// cached response instead
+	var adm admission
+	if c.breaker != nil {
+		var allowed bool
+		adm, allowed = c.breaker.allow()
+		if !allowed {
+			if hasCached {
+				meta.Body, meta.Cached = cached.Body, true
+				return read(bytes.NewReader(cached.Body))
+			}
+			return ErrCircuitOpen
+		}
+	}
+
+	// copy the body for the cache and the caller
+	var buf *bytes.Buffer
+	if c.cache != nil || o.response != nil {
+		buf = &bytes.Buffer{}
+		read = teeRead(read, buf)
+	}
+
+	// invoke the api call
+	status, err := c.invokeWithRetry(ctx, apiMethod, params, read, meta)
+	if respErr, ok := err.(*UnexpectedResponseError); ok {
+		meta.Body = respErr.Body
+	} else if buf != nil {
+		meta.Body = buf.Bytes()
+	}
+
+	// count the outcome, unless the caller gave up on the call
+	if c.breaker != nil {
+		if ctx.Err() != nil {
+			c.breaker.abandon(adm)
+		} else {
+			c.breaker.record(adm, retryable(status, err))
+		}
+	}
+
+	// cache the successful response
+	if c.cache != nil && err == nil {
+		if code, _ := responseError(c.format, buf.Bytes()); code == 0 {
+			c.cache.Set(key, CacheEntry{Body: buf.Bytes(), Stored: time.Now()})
+		}
+	}
+
+	return err
 }
 
-// buildURL builds and returns the oauth API URL based on the given parameters
-func (c *Client) buildURL(apiMethod string, params map[string]string) (string, error) {
-	// get the oauth time parameters
-	ts := fmt.Sprintf("%d", time.Now().Unix())
-	nonce := fmt.Sprintf("%d", rand.New(c.randSrc).Int63())
+// invokeWithRetry invokes the API call, waiting for the rate limiter
+// before each attempt and retrying the failed attempts
+func (c *Client) invokeWithRetry(ctx context.Context, apiMethod string, params map[string]string, read func(io.Reader) error, meta *Response) (int, error) {
+	for attempt := 0; ; attempt++ {
+		// wait for the rate limiter
+		wait, err := c.waitRateLimit(ctx)
+		if c.metrics != nil && c.rateInterval > 0 {
+			c.metrics.ObserveRateLimitWait(apiMethod, wait)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		// invoke the http api call
+		status, err := c.invokeAttempt(ctx, apiMethod, params, attempt, read, meta)
+		if !c.shouldRetry(ctx, attempt, status, err) {
+			return status, err
+		}
 
-	// build the base message
-	m := map[string]string{
-		"method":                 apiMethod,
-		"oauth_consumer_key":     c.consumerKey,
-		"oauth_nonce":            nonce,
-		"oauth_signature_method": "HMAC-SHA1",
-		"oauth_timestamp":        ts,
-		"oauth_version":          "1.0",
-		"format":                 "json",
+		// wait before retrying the failed call
+		if c.metrics != nil {
+			c.metrics.ObserveRetry(apiMethod, attempt+1)
+		}
+		if err := c.waitRetry(ctx, attempt+1); err != nil {
+			return 0, err
+		}
 	}
+}
 
-	// add the given parameters to the message
-	for k, v := range params {
-		m[k] = v
+// invokeAttempt signs and sends a single http attempt of an API call,
+// reporting it to the logger, hooks, metrics and tracer, and recording
+// its metadata in meta
+func (c *Client) invokeAttempt(ctx context.Context, apiMethod string, params map[string]string, attempt int, read func(io.Reader) error, meta *Response) (status int, err error) {
+	// trace the attempt as a child of the logical call
+	ctx, span := c.startAttempt(ctx, apiMethod, attempt)
+	defer func() { c.endSpan(span, err) }()
+
+	// build the oauth api url (with a new nonce for each attempt)
+	apiURL, err := c.buildURL(apiMethod, params)
+	if err != nil {
+		return 0, err
 	}
 
-	// create a sorted array of oauth name keys
-	oauthNames := make([]string, len(m))
-	i := 0
-	for k := range m {
-		oauthNames[i] = k
-		i++
+	// describe the request for the logger and hooks, and copy the body
+	// for them
+	info := requestInfo(apiMethod, apiURL)
+	var buf *bytes.Buffer
+	observing := c.observing()
+	if observing || c.tracer != nil {
+		buf = &bytes.Buffer{}
+		read = teeRead(read, buf)
+	}
+	if observing {
+		c.onRequest(info)
 	}
-	sort.Strings(oauthNames)
 
-	// build the oauth base signature string
-	sigQuery := ""
-	for _, k := range oauthNames {
-		sigQuery += fmt.Sprintf("&%s=%s", k, m[k])
+	// invoke the http api call, redacting the credentials from the error
+	// before it reaches the hooks, metrics, logs, spans or caller
+	start := time.Now()
+	status, header, err := c.doRequest(ctx, apiMethod, apiURL, read)
+	latency := time.Since(start)
+	err = c.redactError(apiURL, err)
+	meta.StatusCode, meta.Header, meta.Latency, meta.URL = status, header, latency, info.URL
+	meta.Attempts++
+	if buf == nil {
+		return status, err
+	}
+	body := buf.Bytes()
+	if respErr, ok := err.(*UnexpectedResponseError); ok {
+		body = respErr.Body
 	}
-	sigQuery = sigEscape(sigQuery[1:])
-	sigBase := fmt.Sprintf("GET&%s&%s", c.escapedAPIURL, sigQuery)
+	errorCode, errorMessage := responseError(c.format, body)
+	errorMessage = c.redactSecrets(apiURL, errorMessage)
 
-	// generate the oauth sha1 base64 signature (no token key)
-	oauthSig := c.signer.Sign("", sigBase)
-	/*
-		// generate the oauth sha1 base64 signature
-		mac := hmac.New(sha1.New, []byte(c.consumerSecret+"&"))
-		mac.Write([]byte(sigBase))
-		oauthSig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
-	*/
+	// add the outcome to the attempt and call spans
+	if c.tracer != nil {
+		span.SetAttributes(IntAttribute(AttrStatusCode, status))
+		if errorCode != 0 {
+			span.SetAttributes(IntAttribute(AttrErrorCode, errorCode))
+			callSpan(ctx).SetAttributes(IntAttribute(AttrErrorCode, errorCode))
+		}
+	}
+
+	// report the outcome to the logger, hooks and metrics, redacting the
+	// credentials which the API echoes in its errors
+	if observing {
+		logBody := ""
+		if c.debug && c.logger != nil {
+			logBody = c.redactSecrets(apiURL, string(body))
+		}
+		c.onResponse(ctx, info, ResponseInfo{
+			Method:       apiMethod,
+			Params:       info.Params,
+			Latency:      latency,
+			StatusCode:   status,
+			Size:         len(body),
+			ErrorCode:    errorCode,
+			ErrorMessage: errorMessage,
+			Err:          err,
+		}, logBody)
+	}
 
-	// add the oauth signature to the map
-	m["oauth_signature"] = oauthSig
-	oauthNames = append(oauthNames, "oauth_signature")
+	return status, err
+}
 
-	// re-sort the keys after adding the signature
-	sort.Strings(oauthNames)
+// doRequest sends the http request for the signed API URL, checks the
+// response and passes its size-limited body to read. It returns the
+// status code and headers.
+func (c *Client) doRequest(ctx context.Context, apiMethod string, apiURL string, read func(io.Reader) error) (int, http.Header, error) {
+	// create the http request bound to the context
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	req = req.WithContext(ctx)
 
-	// build the api request url
-	apiURL := fmt.Sprintf("%s?", c.apiURL)
-	apiQuery := ""
-	for _, k := range oauthNames {
-		apiQuery += fmt.Sprintf("&%s=%s", k, sigEscape(m[k]))
+	// invoke the http api call
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	// check the response before decoding it, keeping the size-limited
+	// body of an unexpected response (ie: an html error page)
+	if err := c.checkResponse(apiMethod, resp); err != nil {
+		if respErr, ok := err.(*UnexpectedResponseError); ok {
+			respErr.Body, _ = ioutil.ReadAll(c.limitBody(apiMethod, resp.Body))
+		}
+		return resp.StatusCode, resp.Header, err
 	}
-	apiURL += apiQuery[1:]
 
-	// return the api url
-	return apiURL, nil
+	// read the response message body, then drain it so the connection
+	// can be reused
+	body := c.limitBody(apiMethod, resp.Body)
+	if err := read(body); err != nil {
+		return resp.StatusCode, resp.Header, err
+	}
+	if _, err := io.Copy(ioutil.Discard, body); err != nil {
+		return resp.StatusCode, resp.Header, err
+	}
+	return resp.StatusCode, resp.Header, nil
 }
 
-// escape the given string using url-escape plus some extras
-func sigEscape(s string) string {
-	s = strings.Replace(s, "%7E", "~", -1)
-	s = strings.Replace(s, "%7e", "~", -1)
-	s = url.QueryEscape(s)
-	s = strings.Replace(s, "+", "%20", -1)
-	s = strings.Replace(s, "!", "%21", -1)
-	s = strings.Replace(s, "\\", "%27", -1)
-	s = strings.Replace(s, "(", "%28", -1)
-	s = strings.Replace(s, ")", "%29", -1)
-	s = strings.Replace(s, "*", "%2A", -1)
-	return s
+// buildURL builds and returns the oauth API URL based on the given parameters
+func (c *Client) buildURL(apiMethod string, params map[string]string) (string, error) {
+	// get the oauth time parameters
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// the random source is shared by concurrent api calls
+	c.randMu.Lock()
+	nonce := strconv.FormatInt(rand.New(c.randSrc).Int63(), 10)
+	c.randMu.Unlock()
+
+	return c.signURL(apiMethod, params, ts, nonce)
+}
+
+// signURL deterministically builds the signed oauth API URL for the
+// given parameters, timestamp and nonce
+func (c *Client) signURL(apiMethod string, params map[string]string, ts string, nonce string) (string, error) {
+	// parse the api url, keeping any query parameters it already has
+	u, err := url.Parse(c.apiURL)
+	if err != nil {
+		return "", err
+	}
+	values := u.Query()
+
+	// add the given parameters
+	for k, v := range params {
+		values.Set(k, v)
+	}
+
+	// add the base oauth parameters
+	values.Set("method", apiMethod)
+	values.Set("format", c.format.String())
+	values.Set("oauth_consumer_key", c.consumerKey)
+	values.Set("oauth_nonce", nonce)
+	values.Set("oauth_signature_method", c.signer.Name())
+	values.Set("oauth_timestamp", ts)
+	values.Set("oauth_version", "1.0")
+
+	// generate the oauth signature (no token secret)
+	sigBase := oauth1.BaseString(http.MethodGet, u, values)
+	values.Set("oauth_signature", c.signer.Sign("", sigBase))
+
+	// build the api request url
+	u.RawQuery = oauth1.EncodeQuery(values)
+	return u.String(), nil
 }
@@ -0,0 +1,531 @@
+package fatsecret_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/fatsecrettest"
+)
+
+// newTestClient starts a fake FatSecret server and returns a client for it
+func newTestClient(t *testing.T) (*fatsecret.Client, *fatsecrettest.Server) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	t.Cleanup(srv.Close)
+	client, err := srv.NewClient()
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	return client, srv
+}
+
+func TestFoodByBarcode(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.AddBarcode("0748927052688", "2415647")
+	srv.AddFood(fatsecret.FoodInfo{
+		ID:   "2415647",
+		Name: "Peanut Butter",
+		Servings: fatsecret.FoodServings{
+			Serving: []fatsecret.FoodServing{{ServingID: "1"}, {ServingID: "2"}},
+		},
+	})
+
+	// a UPC-A barcode is normalized and found
+	food, err := client.FoodByBarcode(context.Background(), "748927052688")
+	if err != nil {
+		t.Fatalf("Could not fetch food: '%v'", err)
+	}
+	if food.Name != "Peanut Butter" || len(food.Servings.Serving) != 2 {
+		t.Errorf("got %+v; want the peanut butter food", food)
+	}
+
+	// an unknown barcode is not found
+	if _, err := client.FoodByBarcode(context.Background(), "0000000000001"); !errors.Is(err, fatsecret.ErrBarcodeNotFound) {
+		t.Errorf("got '%v'; want ErrBarcodeNotFound", err)
+	}
+}
+
+func TestFoodSearchWithOptions(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.AddSearchResults("coffee",
+		fatsecret.FoodSearchItem{ID: "1", Name: "Coffee", Type: fatsecret.FoodTypeGeneric},
+		fatsecret.FoodSearchItem{ID: "2", Name: "Iced Coffee", Type: fatsecret.FoodTypeBrand},
+		fatsecret.FoodSearchItem{ID: "3", Name: "Espresso", Type: fatsecret.FoodTypeGeneric},
+	)
+
+	// all types are returned by default
+	foods, err := client.FoodSearch("coffee")
+	if err != nil || len(foods) != 3 {
+		t.Fatalf("got (%d foods, '%v'); want 3 foods", len(foods), err)
+	}
+
+	// only the branded foods are returned
+	foods, err = client.FoodSearchWithOptions(context.Background(), "coffee", fatsecret.FoodSearchOptions{
+		Type: fatsecret.FoodTypeBrand,
+	})
+	if err != nil || len(foods) != 1 || foods[0].ID != "2" {
+		t.Errorf("got (%+v, '%v'); want the branded food", foods, err)
+	}
+
+	// the second page of one result
+	foods, err = client.FoodSearchWithOptions(context.Background(), "coffee", fatsecret.FoodSearchOptions{
+		PageNumber: 1,
+		MaxResults: 1,
+	})
+	if err != nil || len(foods) != 1 || foods[0].ID != "2" {
+		t.Errorf("got (%+v, '%v'); want the second food", foods, err)
+	}
+}
+
+func TestAllBrands(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.AddBrands(fatsecret.BrandTypeRestaurant, "7-Eleven", "Arby's", "Burger King", "Applebee's")
+	srv.AddBrands(fatsecret.BrandTypeManufacturer, "Kraft")
+
+	// walk all of the restaurant brands
+	brands, err := client.AllBrands(context.Background(), fatsecret.BrandTypeRestaurant)
+	if err != nil {
+		t.Fatalf("Could not fetch brands: '%v'", err)
+	}
+	if len(brands) != 4 || brands[0] != "7-Eleven" {
+		t.Errorf("got %v; want the 4 restaurant brands", brands)
+	}
+
+	// the brand type and starting character are both sent
+	brands, err = client.FoodBrands(context.Background(), fatsecret.BrandQuery{
+		Type:       fatsecret.BrandTypeRestaurant,
+		StartsWith: "a",
+	})
+	if err != nil || len(brands) != 2 {
+		t.Errorf("got (%v, '%v'); want the 2 restaurant brands starting with 'a'", brands, err)
+	}
+}
+
+func TestCategoryTree(t *testing.T) {
+	client, srv := newTestClient(t)
+	srv.AddCategory(fatsecret.FoodCategory{ID: "16", Name: "Desserts"}, "Cake", "Ice Cream")
+	srv.AddCategory(fatsecret.FoodCategory{ID: "2", Name: "Beverages"}, "Coffee")
+
+	// build the tree
+	tree, err := client.CategoryTree(context.Background())
+	if err != nil {
+		t.Fatalf("Could not build tree: '%v'", err)
+	}
+	if node, ok := tree.ByName("desserts"); !ok || len(node.SubCategories) != 2 {
+		t.Errorf("got %+v; want the desserts category", node)
+	}
+
+	// the sub-categories are cached for the next tree
+	before := len(srv.Requests())
+	if _, err := client.CategoryTree(context.Background()); err != nil {
+		t.Fatalf("Could not build tree: '%v'", err)
+	}
+	if got := len(srv.Requests()) - before; got != 1 {
+		t.Errorf("got %d requests; want only the categories request", got)
+	}
+
+	// the sub-categories are cached by locale
+	before = len(srv.Requests())
+	ctx := fatsecret.WithCallOptions(context.Background(), fatsecret.ForLocale(fatsecret.Locale{Region: fatsecret.RegionGermany}))
+	if _, err := client.CategoryTree(ctx); err != nil {
+		t.Fatalf("Could not build tree: '%v'", err)
+	}
+	if got := len(srv.Requests()) - before; got != 3 {
+		t.Errorf("got %d requests; want the categories and sub-categories requests", got)
+	}
+
+	// a failed sub-category call fails the tree
+	client.ResetCategoryCache()
+	srv.InjectFault("food_sub_categories.get", fatsecrettest.Fault{ErrorCode: 12, ErrorMessage: "Too many actions"})
+	if _, err := client.CategoryTree(context.Background()); err == nil {
+		t.Errorf("got no error; want the injected fault")
+	}
+}
+
+func TestCategoryTreeCacheExpires(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	t.Cleanup(srv.Close)
+	client, err := srv.NewClient(fatsecret.WithCategoryCacheTTL(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	srv.AddCategory(fatsecret.FoodCategory{ID: "16", Name: "Desserts"}, "Cake")
+
+	// build the tree
+	if _, err := client.CategoryTree(context.Background()); err != nil {
+		t.Fatalf("Could not build tree: '%v'", err)
+	}
+
+	// the expired sub-categories are fetched again
+	time.Sleep(5 * time.Millisecond)
+	before := len(srv.Requests())
+	if _, err := client.CategoryTree(context.Background()); err != nil {
+		t.Fatalf("Could not build tree: '%v'", err)
+	}
+	if got := len(srv.Requests()) - before; got != 2 {
+		t.Errorf("got %d requests; want the categories and sub-categories requests", got)
+	}
+}
+
+// inFlightTransport records the most http requests sent at once
+type inFlightTransport struct {
+	mu       sync.Mutex
+	inFlight int
+	max      int
+}
+
+func (t *inFlightTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.inFlight++
+	t.max = max(t.max, t.inFlight)
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.inFlight--
+		t.mu.Unlock()
+	}()
+
+	// hold the request so that concurrent requests overlap
+	time.Sleep(10 * time.Millisecond)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestCategoryTreeConcurrency(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	for i := 1; i <= 6; i++ {
+		srv.AddCategory(fatsecret.FoodCategory{ID: fmt.Sprint(i), Name: fmt.Sprintf("Category %d", i)}, "Sub")
+	}
+
+	// define the test-cases
+	testCases := []struct {
+		name string
+		opts []fatsecret.Option
+		want int
+	}{
+		{"concurrency", []fatsecret.Option{fatsecret.WithCategoryConcurrency(2)}, 2},
+		{"rate limit", []fatsecret.Option{fatsecret.WithRateLimit(5 * time.Millisecond)}, 1},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			transport := &inFlightTransport{}
+			client, err := srv.NewClient(append(tc.opts, fatsecret.WithTransport(transport))...)
+			if err != nil {
+				t.Fatalf("Could not create client: '%v'", err)
+			}
+			if _, err := client.CategoryTree(context.Background()); err != nil {
+				t.Fatalf("Could not build tree: '%v'", err)
+			}
+			if transport.max > tc.want {
+				t.Errorf("got %d concurrent requests; want at most %d", transport.max, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithTransportKeepsTimeout(t *testing.T) {
+	// a transport which hangs until the request is cancelled
+	hang := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(5 * time.Second):
+			return nil, errors.New("request was not cancelled")
+		}
+	})
+
+	// the transport must not drop the timeout of the http client
+	client, err := fatsecret.NewClient("test-key", "test-secret",
+		fatsecret.WithHTTPClient(&http.Client{Timeout: 20 * time.Millisecond}),
+		fatsecret.WithTransport(hang))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	start := time.Now()
+	if _, err := client.FoodByID("1"); err == nil {
+		t.Errorf("got no error; want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("got %v; want the call to time out", elapsed)
+	}
+}
+
+// roundTripFunc adapts a function into an http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestFormats(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	srv.AddFood(fatsecret.FoodInfo{
+		ID:   "1",
+		Name: "Apple",
+		Type: fatsecret.FoodTypeGeneric,
+		Servings: fatsecret.FoodServings{Serving: []fatsecret.FoodServing{
+			{ServingID: "10", ServingDescription: "1 medium", Calories: "95"},
+			{ServingID: "11", ServingDescription: "100 g", Calories: "52"},
+		}},
+	})
+	srv.AddSearchResults("apple", fatsecret.FoodSearchItem{ID: "1", Name: "Apple", Type: fatsecret.FoodTypeGeneric})
+	srv.AddBarcode("0748927052688", "1")
+	srv.AddBrands(fatsecret.BrandTypeManufacturer, "Acme", "Bolt")
+	srv.AddCategory(fatsecret.FoodCategory{ID: "1", Name: "Fruit"}, "Apples", "Pears")
+
+	// fetch everything in the given format
+	fetch := func(format fatsecret.Format) []interface{} {
+		client, err := srv.NewClient(fatsecret.WithFormat(format))
+		if err != nil {
+			t.Fatalf("Could not create client: '%v'", err)
+		}
+		ctx := context.Background()
+		food, err := client.FoodByID("1")
+		if err != nil {
+			t.Fatalf("Could not fetch food as %v: '%v'", format, err)
+		}
+		items, err := client.FoodSearch("apple")
+		if err != nil {
+			t.Fatalf("Could not search foods as %v: '%v'", format, err)
+		}
+		id, err := client.FoodIDForBarcode("748927052688")
+		if err != nil {
+			t.Fatalf("Could not find barcode as %v: '%v'", format, err)
+		}
+		brands, err := client.FoodBrands(ctx, fatsecret.BrandQuery{})
+		if err != nil {
+			t.Fatalf("Could not fetch brands as %v: '%v'", format, err)
+		}
+		tree, err := client.CategoryTree(ctx)
+		if err != nil {
+			t.Fatalf("Could not fetch categories as %v: '%v'", format, err)
+		}
+		_, notFound := client.FoodByID("2")
+		return []interface{}{food, items, id, brands, tree.Categories, notFound}
+	}
+
+	// the typed methods return the same values in both formats
+	jsonValues, xmlValues := fetch(fatsecret.FormatJSON), fetch(fatsecret.FormatXML)
+	for i := range jsonValues {
+		if !reflect.DeepEqual(jsonValues[i], xmlValues[i]) {
+			t.Errorf("got '%+v' from xml; want '%+v' from json", xmlValues[i], jsonValues[i])
+		}
+	}
+}
+
+func TestFoodByIDV4(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	want := fatsecret.FoodInfo{
+		ID:   "1",
+		Name: "Greek Yogurt",
+		Type: fatsecret.FoodTypeGeneric,
+		Servings: fatsecret.FoodServings{Serving: []fatsecret.FoodServing{
+			{ServingID: "10", Calories: "100", CalciumMg: "110", AddedSugars: "0", VitaminD: "0.1"},
+			{ServingID: "11", Calories: "59", CalciumMg: "65", IsDefault: "1"},
+		}},
+		SubCategories: &fatsecret.FoodSubCategories{SubCategories: []string{"Yogurt"}},
+		Images: &fatsecret.FoodImages{Image: []fatsecret.FoodImage{
+			{URL: "https://example.com/yogurt.jpg", Type: "0"},
+		}},
+		Attributes: &fatsecret.FoodAttributes{
+			Allergens: &fatsecret.FoodAllergens{Allergen: []fatsecret.FoodAttribute{
+				{ID: "1", Name: fatsecret.AllergenMilk, Value: fatsecret.AttributeYes},
+				{ID: "2", Name: fatsecret.AllergenGluten, Value: fatsecret.AttributeNo},
+			}},
+			Preferences: &fatsecret.FoodPreferences{Preference: []fatsecret.FoodAttribute{
+				{ID: "1", Name: fatsecret.PreferenceVegan, Value: fatsecret.AttributeNo},
+			}},
+		},
+	}
+	srv.AddFood(want)
+
+	// iterate through each format
+	for _, format := range []fatsecret.Format{fatsecret.FormatJSON, fatsecret.FormatXML} {
+		client, err := srv.NewClient(fatsecret.WithFormat(format))
+		if err != nil {
+			t.Fatalf("Could not create client: '%v'", err)
+		}
+
+		// v4 returns every field
+		food, err := client.FoodByIDV4(context.Background(), "1")
+		if err != nil {
+			t.Fatalf("Could not fetch food as %v: '%v'", format, err)
+		}
+		if !reflect.DeepEqual(*food, want) {
+			t.Errorf("got '%+v' as %v; want '%+v'", *food, format, want)
+		}
+		if food.Allergen(fatsecret.AllergenMilk) != fatsecret.AttributeYes ||
+			food.Allergen(fatsecret.AllergenGluten) != fatsecret.AttributeNo ||
+			food.Allergen(fatsecret.AllergenPeanuts) != fatsecret.AttributeUnknown ||
+			food.Preference(fatsecret.PreferenceVegan) != fatsecret.AttributeNo {
+			t.Errorf("got attributes '%+v' as %v; want milk and not gluten or vegan", food.Attributes, format)
+		}
+		if serving := food.DefaultServing(); serving == nil || serving.ServingID != "11" {
+			t.Errorf("got default serving '%+v' as %v; want serving '11'", serving, format)
+		}
+
+		// the original call is unchanged
+		food, err = client.FoodByID("1")
+		if err != nil {
+			t.Fatalf("Could not fetch food as %v: '%v'", format, err)
+		}
+		if food.Images != nil || food.Attributes != nil || food.SubCategories != nil ||
+			food.Servings.Serving[0].VitaminD != "" || food.Servings.Serving[0].CalciumMg != "" ||
+			food.DefaultServing() != nil {
+			t.Errorf("got '%+v' as %v; want no v4 fields", *food, format)
+		}
+		if food.Allergen(fatsecret.AllergenMilk) != fatsecret.AttributeUnknown {
+			t.Errorf("got milk '%v' as %v; want unknown", food.Allergen(fatsecret.AllergenMilk), format)
+		}
+	}
+}
+
+func TestFoodByIDV4NoFood(t *testing.T) {
+	// an api which returns neither a food nor an error
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{}`)
+	}))
+	defer api.Close()
+
+	client, err := fatsecret.NewClient("test-key", "test-secret", fatsecret.WithAPIURL(api.URL))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if food, err := client.FoodByIDV4(context.Background(), "1"); food != nil || err != nil {
+		t.Errorf("got (%+v, '%v'); want no food", food, err)
+	}
+}
+
+func TestFoodSearchV3(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	milk := func(value fatsecret.AttributeValue, vegan fatsecret.AttributeValue) *fatsecret.FoodAttributes {
+		return &fatsecret.FoodAttributes{
+			Allergens: &fatsecret.FoodAllergens{Allergen: []fatsecret.FoodAttribute{
+				{ID: "1", Name: fatsecret.AllergenMilk, Value: value},
+			}},
+			Preferences: &fatsecret.FoodPreferences{Preference: []fatsecret.FoodAttribute{
+				{ID: "1", Name: fatsecret.PreferenceVegan, Value: vegan},
+			}},
+		}
+	}
+	srv.AddFood(fatsecret.FoodInfo{
+		ID: "1", Name: "Yogurt", Type: fatsecret.FoodTypeGeneric,
+		Servings:   fatsecret.FoodServings{Serving: []fatsecret.FoodServing{{ServingID: "10", Calories: "59", IsDefault: "1"}}},
+		Attributes: milk(fatsecret.AttributeYes, fatsecret.AttributeNo),
+	})
+	srv.AddFood(fatsecret.FoodInfo{
+		ID: "2", Name: "Soy Yogurt", Type: fatsecret.FoodTypeBrand, BrandName: "Alpro",
+		Servings:   fatsecret.FoodServings{Serving: []fatsecret.FoodServing{{ServingID: "20", Calories: "50", IsDefault: "1"}}},
+		Attributes: milk(fatsecret.AttributeNo, fatsecret.AttributeYes),
+	})
+	srv.AddSearchResults("yogurt",
+		fatsecret.FoodSearchItem{ID: "1", Name: "Yogurt", Type: fatsecret.FoodTypeGeneric},
+		fatsecret.FoodSearchItem{ID: "2", Name: "Soy Yogurt", Type: fatsecret.FoodTypeBrand, BrandName: "Alpro"},
+		fatsecret.FoodSearchItem{ID: "3", Name: "Frozen Yogurt", Type: fatsecret.FoodTypeBrand, BrandName: "Acme"},
+	)
+
+	// define the test-cases
+	testCases := []struct {
+		name string
+		opts fatsecret.FoodSearchV3Options
+		want []string
+	}{
+		{"all", fatsecret.FoodSearchV3Options{}, []string{"1", "2", "3"}},
+		{"page", fatsecret.FoodSearchV3Options{PageNumber: 1, MaxResults: 2}, []string{"3"}},
+		{"type", fatsecret.FoodSearchV3Options{Filter: fatsecret.FoodFilter{Type: fatsecret.FoodTypeBrand}}, []string{"2", "3"}},
+		{"brand", fatsecret.FoodSearchV3Options{Filter: fatsecret.FoodFilter{Brand: "acme"}}, []string{"3"}},
+		{"allergen", fatsecret.FoodSearchV3Options{Filter: fatsecret.FoodFilter{ExcludeAllergens: []string{fatsecret.AllergenMilk}}}, []string{"2", "3"}},
+		{"preference", fatsecret.FoodSearchV3Options{Filter: fatsecret.FoodFilter{Preferences: []string{fatsecret.PreferenceVegan}}}, []string{"2"}},
+		{"no results", fatsecret.FoodSearchV3Options{PageNumber: 5}, nil},
+	}
+
+	// iterate through each format and test-case
+	for _, format := range []fatsecret.Format{fatsecret.FormatJSON, fatsecret.FormatXML} {
+		client, err := srv.NewClient(fatsecret.WithFormat(format))
+		if err != nil {
+			t.Fatalf("Could not create client: '%v'", err)
+		}
+		for _, tc := range testCases {
+			// run the next sub-test
+			t.Run(fmt.Sprintf("%v/%s", format, tc.name), func(t *testing.T) {
+				foods, err := client.FoodSearchV3(context.Background(), "yogurt", tc.opts)
+				if err != nil {
+					t.Fatalf("Could not search foods: '%v'", err)
+				}
+				var ids []string
+				for _, food := range foods {
+					ids = append(ids, food.ID)
+				}
+				if !reflect.DeepEqual(ids, tc.want) {
+					t.Errorf("got '%v'; want '%v'", ids, tc.want)
+				}
+			})
+		}
+
+		// the servings are embedded and the attributes only when requested
+		foods, err := client.FoodSearchV3(context.Background(), "yogurt", fatsecret.FoodSearchV3Options{})
+		if err != nil {
+			t.Fatalf("Could not search foods as %v: '%v'", format, err)
+		}
+		if serving := foods[0].DefaultServing(); serving == nil || serving.Calories != "59" || foods[0].Attributes != nil {
+			t.Errorf("got '%+v' as %v; want the default serving without attributes", foods[0], format)
+		}
+	}
+}
+
+func TestUnexpectedResponses(t *testing.T) {
+	// a server which returns an html page, a 502 and a huge body
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("food_id") {
+		case "html":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, "<html>Maintenance</html>")
+		case "status":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"food": {"food_id": "1", "food_name": "%s"}}`, strings.Repeat("x", 2048))
+		}
+	}))
+	defer srv.Close()
+	client, err := fatsecret.NewClient("test-key", "test-secret",
+		fatsecret.WithAPIURL(srv.URL),
+		fatsecret.WithMaxResponseSize(1024),
+	)
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// the html page and the bad status are rejected before decoding
+	unexpected := &fatsecret.UnexpectedResponseError{}
+	if _, err := client.FoodByID("html"); !errors.As(err, &unexpected) || unexpected.ContentType != "text/html" {
+		t.Errorf("got error '%v'; want an unexpected content type error", err)
+	}
+	if _, err := client.FoodByID("status"); !errors.As(err, &unexpected) || unexpected.StatusCode != http.StatusBadGateway {
+		t.Errorf("got error '%v'; want an unexpected status error", err)
+	}
+
+	// the huge body is rejected by both the typed and the raw methods
+	tooLarge := &fatsecret.ResponseTooLargeError{}
+	if _, err := client.FoodByID("huge"); !errors.As(err, &tooLarge) || tooLarge.Method != "food.get" {
+		t.Errorf("got error '%v'; want a response too large error", err)
+	}
+	if _, err := client.InvokeAPI("food.get", map[string]string{"food_id": "huge"}); !errors.As(err, &tooLarge) {
+		t.Errorf("got error '%v'; want a response too large error", err)
+	}
+}
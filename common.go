@@ -1,6 +1,6 @@
 package fatsecret
 
 type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int    `json:"code" xml:"code"`
+	Message string `json:"message" xml:"message"`
 }
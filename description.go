@@ -0,0 +1,203 @@
+package fatsecret
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// kilojoules per kilocalorie
+	kilojoulesPerKcal = 4.184
+)
+
+// SearchNutrition is the structured form of the 'food_description'
+// summary returned by the 'foods.search' API call
+type SearchNutrition struct {
+	Basis        string  `json:"basis"`        // the serving basis of the values (ie: "100g" or "1 cup")
+	Calories     float64 `json:"calories"`     // the energy content in kcal
+	Fat          float64 `json:"fat"`          // the total fat content in grams
+	Carbohydrate float64 `json:"carbohydrate"` // the total carbohydrate content in grams
+	Protein      float64 `json:"protein"`      // the protein content in grams
+}
+
+// descriptionPrefixes are the localized words which start the serving
+// basis (ie: "Per 100g")
+var descriptionPrefixes = map[string]bool{
+	"per":  true,
+	"pro":  true,
+	"por":  true,
+	"pour": true,
+	"para": true,
+}
+
+// descriptionLabels maps the localized nutrient labels to a field name
+var descriptionLabels = map[string]string{
+	"calories":      "calories",
+	"calorie":       "calories",
+	"cal":           "calories",
+	"energy":        "calories",
+	"kalorien":      "calories",
+	"calorías":      "calories",
+	"calorias":      "calories",
+	"fat":           "fat",
+	"total fat":     "fat",
+	"fett":          "fat",
+	"grasas":        "fat",
+	"grasa":         "fat",
+	"lipides":       "fat",
+	"carbs":         "carbohydrate",
+	"carb":          "carbohydrate",
+	"carbohydrate":  "carbohydrate",
+	"carbohydrates": "carbohydrate",
+	"kohlenh":       "carbohydrate",
+	"kohlenhydrate": "carbohydrate",
+	"carbh":         "carbohydrate",
+	"carbohidratos": "carbohydrate",
+	"glucides":      "carbohydrate",
+	"protein":       "protein",
+	"prot":          "protein",
+	"eiweiß":        "protein",
+	"eiweiss":       "protein",
+	"proteínas":     "protein",
+	"proteinas":     "protein",
+	"protéines":     "protein",
+	"proteines":     "protein",
+}
+
+// ParseDescription parses the 'food_description' summary of the search
+// item (ie: "Per 100g - Calories: 52kcal | Fat: 0.17g | Carbs: 13.81g | Protein: 0.26g")
+// into structured nutrition. An error is returned for unrecognized descriptions.
+func (f FoodSearchItem) ParseDescription() (*SearchNutrition, error) {
+	return parseDescription(f.Description)
+}
+
+// parseDescription parses a 'food_description' summary string
+func parseDescription(desc string) (*SearchNutrition, error) {
+	invalid := fmt.Errorf("Unrecognized food description '%s'", desc)
+
+	// split the serving basis from the nutrient values
+	i := strings.Index(desc, " - ")
+	if i < 0 {
+		return nil, invalid
+	}
+	head, tail := strings.TrimSpace(desc[:i]), desc[i+3:]
+
+	// strip the localized prefix from the serving basis
+	fields := strings.Fields(head)
+	if len(fields) < 2 || !descriptionPrefixes[strings.ToLower(fields[0])] {
+		return nil, invalid
+	}
+	n := &SearchNutrition{
+		Basis: strings.Join(fields[1:], " "),
+	}
+
+	// parse each of the 'label: value' nutrient pairs
+	found := map[string]bool{}
+	for _, part := range strings.Split(tail, "|") {
+		tokens := strings.SplitN(part, ":", 2)
+		if len(tokens) != 2 {
+			return nil, invalid
+		}
+
+		// skip nutrients which are not part of the summary
+		label := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(tokens[0])), ".")
+		field, ok := descriptionLabels[label]
+		if !ok {
+			continue
+		}
+
+		// parse the nutrient amount and unit
+		amount, unit, ok := parseDescriptionValue(tokens[1])
+		if !ok {
+			return nil, invalid
+		}
+
+		// convert the amount to kcal or grams
+		switch {
+		case field == "calories" && (unit == "kcal" || unit == "cal" || unit == ""):
+			n.Calories = amount
+		case field == "calories" && unit == "kj":
+			n.Calories = amount / kilojoulesPerKcal
+		case field != "calories" && (unit == "g" || unit == ""):
+			n.setMacro(field, amount)
+		case field != "calories" && unit == "mg":
+			n.setMacro(field, amount/1000)
+		default:
+			return nil, invalid
+		}
+		found[field] = true
+	}
+
+	// all of the summary nutrients must be present
+	if len(found) != 4 {
+		return nil, invalid
+	}
+
+	return n, nil
+}
+
+// setMacro sets the grams of the named macro-nutrient
+func (n *SearchNutrition) setMacro(field string, grams float64) {
+	switch field {
+	case "fat":
+		n.Fat = grams
+	case "carbohydrate":
+		n.Carbohydrate = grams
+	case "protein":
+		n.Protein = grams
+	}
+}
+
+// parseDescriptionValue parses a nutrient value such as "13.81g",
+// "0,17 g" or "1.234,5kJ" into its amount and lower-case unit
+func parseDescriptionValue(s string) (float64, string, bool) {
+	s = strings.TrimSpace(s)
+
+	// split the leading number from the unit
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.' || s[i] == ',') {
+		i++
+	}
+	number, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+
+	// normalize the decimal and thousands separators
+	if strings.Contains(number, ".") && strings.Contains(number, ",") {
+		if strings.LastIndex(number, ",") > strings.LastIndex(number, ".") {
+			// ie: "1.234,5"
+			number = strings.Replace(number, ".", "", -1)
+			number = strings.Replace(number, ",", ".", -1)
+		} else {
+			// ie: "1,234.5"
+			number = strings.Replace(number, ",", "", -1)
+		}
+	} else if isThousands(number) {
+		// ie: "1,210"
+		number = strings.Replace(number, ",", "", -1)
+	} else {
+		// ie: "2,5"
+		number = strings.Replace(number, ",", ".", -1)
+	}
+
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return amount, unit, true
+}
+
+// isThousands reports whether the commas of the number separate groups of
+// thousands (ie: "1,210" or "12,345,678") rather than the decimals. A
+// leading group with a leading zero (ie: "0,125") is always a decimal.
+func isThousands(number string) bool {
+	groups := strings.Split(number, ",")
+	if len(groups) < 2 || len(groups[0]) == 0 || len(groups[0]) > 3 || groups[0][0] == '0' {
+		return false
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 {
+			return false
+		}
+	}
+	return true
+}
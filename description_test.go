@@ -0,0 +1,100 @@
+package fatsecret
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseDescription(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		desc string
+		want SearchNutrition
+	}{
+		{
+			"Per 100g - Calories: 52kcal | Fat: 0.17g | Carbs: 13.81g | Protein: 0.26g",
+			SearchNutrition{"100g", 52, 0.17, 13.81, 0.26},
+		},
+		{
+			"Per 1 cup - Calories: 149kcal | Fat: 7.93g | Carbs: 11.71g | Protein: 7.69g",
+			SearchNutrition{"1 cup", 149, 7.93, 11.71, 7.69},
+		},
+		{
+			"Pro 100g - Kalorien: 52kcal | Fett: 0,17g | Kohlenh.: 13,81g | Eiweiß: 0,26g",
+			SearchNutrition{"100g", 52, 0.17, 13.81, 0.26},
+		},
+		{
+			"Per 100 g - Energy: 1.046,0kJ | Fat: 0,17 g | Carbs: 13,81 g | Protein: 260mg",
+			SearchNutrition{"100 g", 250, 0.17, 13.81, 0.26},
+		},
+		{
+			"Per 1 serving - Calories: 1,210.5kcal | Fat: 50.5g | Carbs: 120g | Protein: 60g",
+			SearchNutrition{"1 serving", 1210.5, 50.5, 120, 60},
+		},
+		{
+			"Per 1 serving - Calories: 1,210kcal | Fat: 2,5g | Carbs: 120g | Protein: 60g",
+			SearchNutrition{"1 serving", 1210, 2.5, 120, 60},
+		},
+		{
+			"Pro 100g - Kalorien: 1,500kcal | Fett: 0,125g | Kohlenh.: 1,500g | Eiweiß: 0,5g",
+			SearchNutrition{"100g", 1500, 0.125, 1500, 0.5},
+		},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.desc, func(t *testing.T) {
+			item := FoodSearchItem{Description: tc.desc}
+			got, err := item.ParseDescription()
+			if err != nil {
+				t.Fatalf("Could not parse description: '%v'", err)
+			}
+			if got.Basis != tc.want.Basis ||
+				math.Abs(got.Calories-tc.want.Calories) > 0.01 ||
+				math.Abs(got.Fat-tc.want.Fat) > 0.001 ||
+				math.Abs(got.Carbohydrate-tc.want.Carbohydrate) > 0.001 ||
+				math.Abs(got.Protein-tc.want.Protein) > 0.001 {
+				t.Errorf("got %+v; want %+v", *got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseDescriptionInvalid(t *testing.T) {
+	// define the invalid descriptions
+	testCases := []string{
+		"",
+		"Per 100g",
+		"100g - Calories: 52kcal | Fat: 0.17g | Carbs: 13.81g | Protein: 0.26g",
+		"Per 100g - Calories: 52kcal | Fat: 0.17g | Carbs: 13.81g",
+		"Per 100g - Calories: lots | Fat: 0.17g | Carbs: 13.81g | Protein: 0.26g",
+		"Per 100g - Calories: 52kcal | Fat: 0.17cups | Carbs: 13.81g | Protein: 0.26g",
+	}
+
+	// iterate through each test-case
+	for _, desc := range testCases {
+		// run the next sub-test
+		t.Run(desc, func(t *testing.T) {
+			if got, err := parseDescription(desc); err == nil {
+				t.Errorf("got %+v; want an error", *got)
+			}
+		})
+	}
+}
+
+func FuzzParseDescription(f *testing.F) {
+	// seed the corpus with known descriptions
+	f.Add("Per 100g - Calories: 52kcal | Fat: 0.17g | Carbs: 13.81g | Protein: 0.26g")
+	f.Add("Pro 100g - Kalorien: 52kcal | Fett: 0,17g | Kohlenh.: 13,81g | Eiweiß: 0,26g")
+	f.Add("Per 1 cup - Energy: 1.046,0kJ | Fat: 0,17 g | Carbs: 13,81 g | Protein: 260mg")
+	f.Add("Per - : | :: | - -")
+
+	f.Fuzz(func(t *testing.T, desc string) {
+		// the parser must never panic, and must return a basis on success
+		n, err := parseDescription(desc)
+		if err == nil && (n == nil || n.Basis == "") {
+			t.Errorf("got %+v for '%s'; want a serving basis", n, desc)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package fatsecrettest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fitzone/fatsecret"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "food.json")
+
+	// record the traffic to the fake server
+	srv := NewServer("recorded-key", "recorded-secret")
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+	rec, err := NewRecorder(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("Could not create recorder: '%v'", err)
+	}
+	client, err := srv.NewClient(fatsecret.WithTransport(rec))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if _, err := client.FoodByID("1"); err != nil {
+		t.Fatalf("Could not fetch food: '%v'", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Could not save cassette: '%v'", err)
+	}
+	srv.Close()
+
+	// the credentials and signature were scrubbed
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Could not read cassette: '%v'", err)
+	}
+	for _, secret := range []string{"recorded-key", "oauth_signature=", "oauth_nonce=", "oauth_timestamp="} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains '%s'", secret)
+		}
+	}
+
+	// replay the traffic with the server closed and other credentials
+	rep, err := NewRecorder(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("Could not load cassette: '%v'", err)
+	}
+	client, err = fatsecret.NewClient("other-key", "other-secret",
+		fatsecret.WithAPIURL(srv.URL), fatsecret.WithTransport(rep))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	food, err := client.FoodByID("1")
+	if err != nil || food.Name != "Apple" {
+		t.Errorf("got (%+v, '%v'); want the replayed food", food, err)
+	}
+
+	// requests which were not recorded fail
+	if _, err := client.FoodByID("2"); err == nil {
+		t.Errorf("got no error; want a missing interaction error")
+	}
+}
+
+func TestRecorderScrubsResponses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "error.json")
+
+	// an api which echoes the credentials and signature in its error
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		w.Header().Set("X-Consumer-Key", params.Get("oauth_consumer_key"))
+		fmt.Fprintf(w, `{"error": {"code": 8, "message": "Invalid signature: oauth_signature '%s' for key '%s' (%s)"}}`,
+			params.Get("oauth_signature"), params.Get("oauth_consumer_key"), r.URL.RawQuery)
+	}))
+	defer api.Close()
+
+	// record the failed call
+	rec, err := NewRecorder(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("Could not create recorder: '%v'", err)
+	}
+	client, err := fatsecret.NewClient("recorded-key", "recorded-secret",
+		fatsecret.WithAPIURL(api.URL), fatsecret.WithTransport(rec))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if _, err := client.FoodByID("1"); err == nil {
+		t.Fatalf("got no error; want the signature error")
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Could not save cassette: '%v'", err)
+	}
+
+	// the credentials and signature were scrubbed from the response
+	cassette := Cassette{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Could not read cassette: '%v'", err)
+	}
+	if err := json.Unmarshal(data, &cassette); err != nil || len(cassette.Interactions) != 1 {
+		t.Fatalf("got (%+v, '%v'); want one interaction", cassette, err)
+	}
+	in := cassette.Interactions[0]
+	if strings.Contains(in.Body, "recorded-key") || strings.Contains(in.Header.Get("X-Consumer-Key"), "recorded-key") {
+		t.Errorf("got '%s' '%v'; want the consumer key scrubbed", in.Body, in.Header)
+	}
+	if !strings.Contains(in.Body, "oauth_signature 'REDACTED'") || !strings.Contains(in.Body, "oauth_signature=REDACTED") {
+		t.Errorf("got '%s'; want the signature scrubbed", in.Body)
+	}
+}
@@ -0,0 +1,398 @@
+package fatsecrettest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fitzone/fatsecret"
+)
+
+// Call is a method call made on a Fake
+type Call struct {
+	Method string        // the service method name (ie: "FoodByID")
+	Args   []interface{} // the call arguments, excluding the context
+}
+
+// Fake is an in-memory implementation of the fatsecret FoodService,
+// BrandService and CategoryService interfaces, backed by Go data. It
+// records every call, and errors can be programmed per method. Call
+// options carried by the context, such as WithResponse, are ignored.
+type Fake struct {
+	mu            sync.Mutex
+	foods         map[string]fatsecret.FoodInfo
+	searches      map[string][]fatsecret.FoodSearchItem
+	barcodes      map[string]string
+	brands        map[fatsecret.BrandType][]string
+	categories    []fatsecret.FoodCategory
+	subCategories map[string][]string
+	errs          map[string]error
+	calls         []Call
+}
+
+// verify the fake implements all of the services
+var (
+	_ fatsecret.FoodService     = (*Fake)(nil)
+	_ fatsecret.BrandService    = (*Fake)(nil)
+	_ fatsecret.CategoryService = (*Fake)(nil)
+)
+
+// NewFake creates and returns an empty fake
+func NewFake() *Fake {
+	return &Fake{
+		foods:         map[string]fatsecret.FoodInfo{},
+		searches:      map[string][]fatsecret.FoodSearchItem{},
+		barcodes:      map[string]string{},
+		brands:        map[fatsecret.BrandType][]string{},
+		subCategories: map[string][]string{},
+		errs:          map[string]error{},
+	}
+}
+
+// AddFood adds a copy of a food which is returned by FoodByID and
+// FoodByBarcode. FoodByIDV4 and FoodSearchV3 return its absolute amounts
+// (ie: CalciumMg) without the percentages, like the client does with the
+// API's v4 responses.
+func (f *Fake) AddFood(food fatsecret.FoodInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.foods[food.ID] = copyFood(food)
+}
+
+// AddSearchResults adds food items which are returned when searching
+// for the given query (not case sensitive)
+func (f *Fake) AddSearchResults(query string, items ...fatsecret.FoodSearchItem) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	query = strings.ToLower(query)
+	f.searches[query] = append(f.searches[query], items...)
+}
+
+// AddBarcode maps a barcode to a food id
+func (f *Fake) AddBarcode(barcode string, foodID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if normalized, err := fatsecret.NormalizeBarcode(barcode); err == nil {
+		barcode = normalized
+	}
+	f.barcodes[barcode] = foodID
+}
+
+// AddBrands adds brands of the given type
+func (f *Fake) AddBrands(brandType fatsecret.BrandType, brands ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.brands[brandType] = append(f.brands[brandType], brands...)
+}
+
+// AddCategory adds a category and its sub-categories
+func (f *Fake) AddCategory(category fatsecret.FoodCategory, subCategories ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.categories = append(f.categories, category)
+	f.subCategories[category.ID] = append(f.subCategories[category.ID], subCategories...)
+}
+
+// SetError makes the named method (ie: "FoodByID") return the error.
+// A nil error clears it.
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// Calls returns the calls made on the fake, in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallCount returns the number of calls made to the named method
+func (f *Fake) CallCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, c := range f.calls {
+		if c.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+// FoodSearch returns the food items added for the query
+func (f *Fake) FoodSearch(query string) ([]fatsecret.FoodSearchItem, error) {
+	if err := f.record("FoodSearch", query); err != nil {
+		return nil, err
+	}
+	return f.search(query, fatsecret.FoodSearchOptions{}), nil
+}
+
+// FoodSearchWithOptions returns the page of food items added for the query
+func (f *Fake) FoodSearchWithOptions(ctx context.Context, query string, opts fatsecret.FoodSearchOptions) ([]fatsecret.FoodSearchItem, error) {
+	if err := f.record("FoodSearchWithOptions", query, opts); err != nil {
+		return nil, err
+	}
+	return f.search(query, opts), nil
+}
+
+// FoodSearchV3 returns the page of foods added for the query, using the
+// food added for each item's id, or else the item's own fields
+func (f *Fake) FoodSearchV3(ctx context.Context, query string, opts fatsecret.FoodSearchV3Options) ([]fatsecret.FoodInfo, error) {
+	if err := f.record("FoodSearchV3", query, opts); err != nil {
+		return nil, err
+	}
+	items := f.search(query, fatsecret.FoodSearchOptions{PageNumber: opts.PageNumber, MaxResults: opts.MaxResults})
+	if len(items) == 0 {
+		return nil, nil
+	}
+	foods := make([]fatsecret.FoodInfo, len(items))
+	f.mu.Lock()
+	for i, item := range items {
+		foods[i] = fromV4(toV4(searchFood(f.foods, item)))
+	}
+	f.mu.Unlock()
+	return opts.Filter.Apply(foods), nil
+}
+
+// FoodIDForBarcode returns the food id added for the barcode, or "0"
+func (f *Fake) FoodIDForBarcode(barcode string) (string, error) {
+	if err := f.record("FoodIDForBarcode", barcode); err != nil {
+		return "", err
+	}
+	return f.foodIDForBarcode(barcode)
+}
+
+// FoodByID returns the food added for the id
+func (f *Fake) FoodByID(id string) (*fatsecret.FoodInfo, error) {
+	if err := f.record("FoodByID", id); err != nil {
+		return nil, err
+	}
+	return f.food(id)
+}
+
+// FoodByIDV4 returns the food added for the id, with the absolute
+// amounts of its servings in place of the percentages
+func (f *Fake) FoodByIDV4(ctx context.Context, id string) (*fatsecret.FoodInfo, error) {
+	if err := f.record("FoodByIDV4", id); err != nil {
+		return nil, err
+	}
+	food, err := f.food(id)
+	if err != nil {
+		return nil, err
+	}
+	v4 := fromV4(toV4(*food))
+	return &v4, nil
+}
+
+// FoodByBarcode returns the food added for the barcode, or
+// fatsecret.ErrBarcodeNotFound
+func (f *Fake) FoodByBarcode(ctx context.Context, barcode string) (*fatsecret.FoodInfo, error) {
+	if err := f.record("FoodByBarcode", barcode); err != nil {
+		return nil, err
+	}
+	id, err := f.foodIDForBarcode(barcode)
+	if err != nil {
+		return nil, err
+	}
+	if id == "0" {
+		return nil, fatsecret.ErrBarcodeNotFound
+	}
+	return f.food(id)
+}
+
+// FoodBrandsByType returns the brands added for the type
+func (f *Fake) FoodBrandsByType(brandType fatsecret.BrandType) ([]string, error) {
+	if err := f.record("FoodBrandsByType", brandType); err != nil {
+		return nil, err
+	}
+	return f.filterBrands(brandType, "")
+}
+
+// FoodBrandsStartingWith returns the manufacturer brands with the starting character
+func (f *Fake) FoodBrandsStartingWith(startsWith string) ([]string, error) {
+	if err := f.record("FoodBrandsStartingWith", startsWith); err != nil {
+		return nil, err
+	}
+	return f.filterBrands(fatsecret.BrandTypeManufacturer, startsWith)
+}
+
+// FoodBrands returns the brands matching the query
+func (f *Fake) FoodBrands(ctx context.Context, q fatsecret.BrandQuery) ([]string, error) {
+	if err := f.record("FoodBrands", q); err != nil {
+		return nil, err
+	}
+	return f.filterBrands(q.Type, q.StartsWith)
+}
+
+// AllBrands returns all of the brands added for the type
+func (f *Fake) AllBrands(ctx context.Context, brandType fatsecret.BrandType) ([]string, error) {
+	if err := f.record("AllBrands", brandType); err != nil {
+		return nil, err
+	}
+	return f.filterBrands(brandType, "")
+}
+
+// FoodCategories returns the categories added to the fake
+func (f *Fake) FoodCategories() ([]fatsecret.FoodCategory, error) {
+	if err := f.record("FoodCategories"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fatsecret.FoodCategory(nil), f.categories...), nil
+}
+
+// FoodSubCategories returns the sub-categories added for the category id
+func (f *Fake) FoodSubCategories(id string) ([]string, error) {
+	if err := f.record("FoodSubCategories", id); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	subs, ok := f.subCategories[id]
+	if !ok {
+		return nil, errors.New("Invalid ID: food_category_id")
+	}
+	return append([]string(nil), subs...), nil
+}
+
+// CategoryTree returns the tree of the categories added to the fake
+func (f *Fake) CategoryTree(ctx context.Context) (*fatsecret.CategoryTree, error) {
+	if err := f.record("CategoryTree"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	nodes := []*fatsecret.CategoryNode{}
+	for _, cat := range f.categories {
+		nodes = append(nodes, &fatsecret.CategoryNode{
+			ID:            cat.ID,
+			Name:          cat.Name,
+			Description:   cat.Description,
+			SubCategories: append([]string(nil), f.subCategories[cat.ID]...),
+		})
+	}
+	return fatsecret.NewCategoryTree(nodes), nil
+}
+
+// record records the call and returns the error programmed for the method
+func (f *Fake) record(method string, args ...interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	return f.errs[method]
+}
+
+// search returns the requested page of food items for the query
+func (f *Fake) search(query string, opts fatsecret.FoodSearchOptions) []fatsecret.FoodSearchItem {
+	f.mu.Lock()
+	items := f.searches[strings.ToLower(query)]
+	f.mu.Unlock()
+
+	// default to the api page size
+	size := opts.MaxResults
+	if size <= 0 {
+		size = 20
+	}
+	page := pageItems(items, opts.PageNumber, size)
+	return fatsecret.FilterFoodsByType(append([]fatsecret.FoodSearchItem(nil), page...), opts.Type)
+}
+
+// foodIDForBarcode returns the food id for the barcode, or "0" when unknown
+func (f *Fake) foodIDForBarcode(barcode string) (string, error) {
+	barcode, err := fatsecret.NormalizeBarcode(barcode)
+	if err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id, ok := f.barcodes[barcode]; ok {
+		return id, nil
+	}
+	return "0", nil
+}
+
+// food returns a copy of the food with the given id
+func (f *Fake) food(id string) (*fatsecret.FoodInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	food, ok := f.foods[id]
+	if !ok {
+		return nil, fmt.Errorf("Invalid ID: food_id '%s'", id)
+	}
+	food = copyFood(food)
+	return &food, nil
+}
+
+// filterBrands returns the brands of the type with the starting
+// character, rejecting an invalid type like the client does
+func (f *Fake) filterBrands(brandType fatsecret.BrandType, startsWith string) ([]string, error) {
+	if _, err := brandType.MarshalText(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return filterBrands(f.brands[brandType], startsWith), nil
+}
+
+// fromV4 returns a copy of a food served by the v4 methods with the
+// absolute amounts of its servings moved out of the percentage fields,
+// like the client does
+func fromV4(food fatsecret.FoodInfo) fatsecret.FoodInfo {
+	food = copyFood(food)
+	for i := range food.Servings.Serving {
+		serving := &food.Servings.Serving[i]
+		if serving.VitaminA != "" {
+			serving.VitaminAMcg, serving.VitaminA = serving.VitaminA, ""
+		}
+		if serving.VitaminC != "" {
+			serving.VitaminCMg, serving.VitaminC = serving.VitaminC, ""
+		}
+		if serving.Calcium != "" {
+			serving.CalciumMg, serving.Calcium = serving.Calcium, ""
+		}
+		if serving.Iron != "" {
+			serving.IronMg, serving.Iron = serving.Iron, ""
+		}
+	}
+	return food
+}
+
+// copyFood returns a deep copy of the food, so that callers cannot
+// modify the foods held by the fake
+func copyFood(food fatsecret.FoodInfo) fatsecret.FoodInfo {
+	food.Servings.Serving = append([]fatsecret.FoodServing(nil), food.Servings.Serving...)
+	if food.SubCategories != nil {
+		food.SubCategories = &fatsecret.FoodSubCategories{
+			SubCategories: append([]string(nil), food.SubCategories.SubCategories...),
+		}
+	}
+	if food.Images != nil {
+		food.Images = &fatsecret.FoodImages{
+			Image: append([]fatsecret.FoodImage(nil), food.Images.Image...),
+		}
+	}
+	if food.Attributes != nil {
+		attributes := &fatsecret.FoodAttributes{}
+		if food.Attributes.Allergens != nil {
+			attributes.Allergens = &fatsecret.FoodAllergens{
+				Allergen: append([]fatsecret.FoodAttribute(nil), food.Attributes.Allergens.Allergen...),
+			}
+		}
+		if food.Attributes.Preferences != nil {
+			attributes.Preferences = &fatsecret.FoodPreferences{
+				Preference: append([]fatsecret.FoodAttribute(nil), food.Attributes.Preferences.Preference...),
+			}
+		}
+		food.Attributes = attributes
+	}
+	return food
+}
@@ -0,0 +1,125 @@
+package fatsecrettest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fitzone/fatsecret"
+)
+
+func TestFake(t *testing.T) {
+	fake := NewFake()
+	fake.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+	fake.AddBarcode("748927052688", "1")
+
+	// use the fake through the service interface
+	var foods fatsecret.FoodService = fake
+	food, err := foods.FoodByBarcode(context.Background(), "0748927052688")
+	if err != nil || food.Name != "Apple" {
+		t.Errorf("got (%+v, '%v'); want the apple", food, err)
+	}
+	if _, err := foods.FoodByBarcode(context.Background(), "1"); !errors.Is(err, fatsecret.ErrBarcodeNotFound) {
+		t.Errorf("got '%v'; want ErrBarcodeNotFound", err)
+	}
+
+	// v3 searches return the added foods
+	fake.AddSearchResults("apple",
+		fatsecret.FoodSearchItem{ID: "1", Name: "Apple"},
+		fatsecret.FoodSearchItem{ID: "2", Name: "Apple Pie", Type: fatsecret.FoodTypeBrand},
+	)
+	found, err := foods.FoodSearchV3(context.Background(), "apple", fatsecret.FoodSearchV3Options{
+		Filter: fatsecret.FoodFilter{Type: fatsecret.FoodTypeBrand},
+	})
+	if err != nil || len(found) != 1 || found[0].Name != "Apple Pie" {
+		t.Errorf("got (%+v, '%v'); want the apple pie", found, err)
+	}
+
+	// programmed errors are returned
+	quota := errors.New("quota exceeded")
+	fake.SetError("FoodByID", quota)
+	if _, err := foods.FoodByID("1"); err != quota {
+		t.Errorf("got '%v'; want the programmed error", err)
+	}
+
+	// the calls were recorded
+	if got := fake.CallCount("FoodByBarcode"); got != 2 {
+		t.Errorf("got %d FoodByBarcode calls; want 2", got)
+	}
+	calls := fake.Calls()
+	if len(calls) != 4 || calls[3].Method != "FoodByID" || calls[3].Args[0] != "1" {
+		t.Errorf("got calls %+v; want the FoodByID call last", calls)
+	}
+}
+
+func TestFakeCopiesFoods(t *testing.T) {
+	fake := NewFake()
+	fake.AddFood(fatsecret.FoodInfo{
+		ID:            "1",
+		Name:          "Apple",
+		Servings:      fatsecret.FoodServings{Serving: []fatsecret.FoodServing{{Calories: "95"}}},
+		SubCategories: &fatsecret.FoodSubCategories{SubCategories: []string{"Fruit"}},
+	})
+
+	// modifying a returned food must not change the stored food
+	food, err := fake.FoodByID("1")
+	if err != nil {
+		t.Fatalf("Could not get food: '%v'", err)
+	}
+	food.Servings.Serving[0].Calories = "0"
+	food.SubCategories.SubCategories[0] = "Candy"
+
+	food, err = fake.FoodByID("1")
+	if err != nil {
+		t.Fatalf("Could not get food: '%v'", err)
+	}
+	if got := food.Servings.Serving[0].Calories; got != "95" {
+		t.Errorf("got '%s' calories; want '95'", got)
+	}
+	if got := food.SubCategories.SubCategories[0]; got != "Fruit" {
+		t.Errorf("got sub-category '%s'; want 'Fruit'", got)
+	}
+}
+
+func TestFakeInvalidBrandType(t *testing.T) {
+	fake := NewFake()
+
+	// an invalid brand type is rejected, like the client does
+	if _, err := fake.FoodBrandsByType(fatsecret.BrandType(42)); err == nil {
+		t.Errorf("got no error; want an invalid brand type error")
+	}
+	if _, err := fake.FoodBrands(context.Background(), fatsecret.BrandQuery{Type: fatsecret.BrandType(42)}); err == nil {
+		t.Errorf("got no error; want an invalid brand type error")
+	}
+}
+
+func TestFakeV4Amounts(t *testing.T) {
+	fake := NewFake()
+	fake.AddFood(fatsecret.FoodInfo{
+		ID:       "1",
+		Name:     "Milk",
+		Servings: fatsecret.FoodServings{Serving: []fatsecret.FoodServing{{Calcium: "30", CalciumMg: "300"}}},
+	})
+	fake.AddSearchResults("milk", fatsecret.FoodSearchItem{ID: "1", Name: "Milk"})
+
+	// the v4 methods return the absolute amounts without the percentages
+	food, err := fake.FoodByIDV4(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Could not get food: '%v'", err)
+	}
+	found, err := fake.FoodSearchV3(context.Background(), "milk", fatsecret.FoodSearchV3Options{})
+	if err != nil || len(found) != 1 {
+		t.Fatalf("got (%+v, '%v'); want the milk", found, err)
+	}
+	for _, serving := range []fatsecret.FoodServing{food.Servings.Serving[0], found[0].Servings.Serving[0]} {
+		if serving.Calcium != "" || serving.CalciumMg != "300" {
+			t.Errorf("got calcium '%s' and '%s' mg; want only '300' mg", serving.Calcium, serving.CalciumMg)
+		}
+	}
+
+	// the older methods still return the food as added
+	food, err = fake.FoodByID("1")
+	if err != nil || food.Servings.Serving[0].Calcium != "30" {
+		t.Errorf("got (%+v, '%v'); want the calcium percentage", food, err)
+	}
+}
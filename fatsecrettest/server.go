@@ -0,0 +1,589 @@
+/*
+Package fatsecrettest provides a local stand-in for the FatSecret API
+which can be used to test code using the fatsecret client without
+network access or real credentials.
+
+The server verifies the Oauth1 signature of each request against the
+configured consumer secret and serves canned responses for the
+supported API methods. Faults such as error codes, 5xx responses,
+latency and malformed JSON can be injected per API method.
+
+Code which depends on the fatsecret service interfaces, rather than the
+concrete client, can use the in-memory Fake instead of a server. Real API
+traffic can be recorded and replayed with a cassette Recorder.
+*/
+package fatsecrettest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/oauth1"
+)
+
+const (
+	// the path of the API endpoint on the server
+	apiPath = "/rest/server.api"
+)
+
+// FatSecret API error codes returned by the server
+const (
+	ErrorCodeMissingOauthParam    = 2
+	ErrorCodeInvalidSignatureType = 4
+	ErrorCodeInvalidConsumerKey   = 5
+	ErrorCodeInvalidSignature     = 8
+	ErrorCodeMissingParam         = 101
+	ErrorCodeInvalidID            = 106
+	ErrorCodeValueOutOfRange      = 107
+	ErrorCodeInvalidMethod        = 13
+)
+
+// Fault describes an error to inject into the responses of an API method
+type Fault struct {
+	ErrorCode    int           // return a FatSecret error response with this code
+	ErrorMessage string        // the message of the error response
+	Status       int           // return this http status code (ie: 503) with an empty body
+	Latency      time.Duration // delay the response by this duration
+	Malformed    bool          // return a truncated, malformed body in the requested format
+	Count        int           // the number of responses to affect; zero affects all of them
+}
+
+// Request is an API request received by the server
+type Request struct {
+	Method string     // the API method (ie: "food.get")
+	Params url.Values // the request parameters, including the oauth parameters
+}
+
+// Server is a local FatSecret API server
+type Server struct {
+	// URL is the API endpoint of the server, for use with fatsecret.WithAPIURL
+	URL string
+
+	// the credentials which requests must be signed with
+	ConsumerKey    string
+	ConsumerSecret string
+
+	srv *httptest.Server
+
+	mu            sync.Mutex
+	foods         map[string]fatsecret.FoodInfo
+	searches      map[string][]fatsecret.FoodSearchItem
+	barcodes      map[string]string
+	brands        map[fatsecret.BrandType][]string
+	categories    []fatsecret.FoodCategory
+	subCategories map[string][]string
+	faults        map[string]*Fault
+	requests      []Request
+}
+
+// NewServer starts and returns a new server which accepts requests signed
+// with the given credentials. The server must be closed when done.
+func NewServer(consumerKey string, consumerSecret string) *Server {
+	s := &Server{
+		ConsumerKey:    consumerKey,
+		ConsumerSecret: consumerSecret,
+		foods:          map[string]fatsecret.FoodInfo{},
+		searches:       map[string][]fatsecret.FoodSearchItem{},
+		barcodes:       map[string]string{},
+		brands:         map[fatsecret.BrandType][]string{},
+		subCategories:  map[string][]string{},
+		faults:         map[string]*Fault{},
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL + apiPath
+	return s
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// NewClient returns a fatsecret client which uses the server
+func (s *Server) NewClient(opts ...fatsecret.Option) (*fatsecret.Client, error) {
+	opts = append([]fatsecret.Option{fatsecret.WithAPIURL(s.URL)}, opts...)
+	return fatsecret.NewClient(s.ConsumerKey, s.ConsumerSecret, opts...)
+}
+
+// AddFood adds a food which is served by 'food.get'. Its images,
+// attributes, sub-categories and v4 serving fields are only served by
+// 'food.get.v4', which serves the absolute amounts (ie: CalciumMg) in
+// place of the percentages, like the API does.
+func (s *Server) AddFood(food fatsecret.FoodInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.foods[food.ID] = food
+}
+
+// AddSearchResults adds food items which are served by 'foods.search'
+// for the given search expression (not case sensitive)
+func (s *Server) AddSearchResults(query string, items ...fatsecret.FoodSearchItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	query = strings.ToLower(query)
+	s.searches[query] = append(s.searches[query], items...)
+}
+
+// AddBarcode maps a GTIN-13 barcode to a food id for 'food.find_id_for_barcode'
+func (s *Server) AddBarcode(barcode string, foodID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.barcodes[barcode] = foodID
+}
+
+// AddBrands adds brands of the given type which are served by 'food_brands.get'
+func (s *Server) AddBrands(brandType fatsecret.BrandType, brands ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.brands[brandType] = append(s.brands[brandType], brands...)
+}
+
+// AddCategory adds a category, and its sub-categories, which are served
+// by 'food_categories.get' and 'food_sub_categories.get'
+func (s *Server) AddCategory(category fatsecret.FoodCategory, subCategories ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.categories = append(s.categories, category)
+	s.subCategories[category.ID] = append(s.subCategories[category.ID], subCategories...)
+}
+
+// InjectFault injects the fault into the responses of the given API
+// method. An empty method injects the fault into all methods.
+func (s *Server) InjectFault(method string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[method] = &fault
+}
+
+// ClearFaults removes all of the injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = map[string]*Fault{}
+}
+
+// Requests returns the API requests received by the server, in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// serveHTTP handles an API request
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	method := params.Get("method")
+
+	// record the request
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: method, Params: params})
+	fault := s.takeFault(method)
+	s.mu.Unlock()
+
+	// apply any injected fault
+	if fault != nil {
+		if fault.Latency > 0 {
+			select {
+			case <-time.After(fault.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		switch {
+		case fault.Status != 0:
+			w.WriteHeader(fault.Status)
+			return
+		case fault.Malformed:
+			if params.Get("format") == "xml" {
+				w.Header().Set("Content-Type", "application/xml")
+				fmt.Fprint(w, xml.Header+`<food><food_id>1`)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"food": {"food_id": "1`)
+			return
+		case fault.ErrorCode != 0:
+			writeError(w, params, fault.ErrorCode, fault.ErrorMessage)
+			return
+		}
+	}
+
+	// verify the oauth signature
+	if code, msg := s.verify(r, params); code != 0 {
+		writeError(w, params, code, msg)
+		return
+	}
+
+	// serve the api method
+	switch method {
+	case "foods.search":
+		s.serveFoodSearch(w, params)
+	case "foods.search.v3":
+		s.serveFoodSearchV3(w, params)
+	case "food.get", "food.get.v2":
+		s.serveFood(w, params, false)
+	case "food.get.v4":
+		s.serveFood(w, params, true)
+	case "food.find_id_for_barcode":
+		s.serveBarcode(w, params)
+	case "food_brands.get":
+		s.serveBrands(w, params)
+	case "food_categories.get":
+		s.serveCategories(w, params)
+	case "food_sub_categories.get":
+		s.serveSubCategories(w, params)
+	default:
+		writeError(w, params, ErrorCodeInvalidMethod, fmt.Sprintf("Unknown method: '%s'", method))
+	}
+}
+
+// takeFault returns the fault to apply to the method, if any, and
+// counts it down. The lock must be held.
+func (s *Server) takeFault(method string) *Fault {
+	key := method
+	fault, ok := s.faults[key]
+	if !ok {
+		key = ""
+		fault, ok = s.faults[key]
+	}
+	if !ok {
+		return nil
+	}
+	if fault.Count > 0 {
+		fault.Count--
+		if fault.Count == 0 {
+			delete(s.faults, key)
+		}
+	}
+	return fault
+}
+
+// verify checks the oauth parameters and signature of the request,
+// returning a FatSecret error code and message when invalid
+func (s *Server) verify(r *http.Request, params url.Values) (int, string) {
+	// check the required oauth parameters
+	for _, name := range []string{"oauth_consumer_key", "oauth_signature_method", "oauth_timestamp", "oauth_nonce", "oauth_signature"} {
+		if params.Get(name) == "" {
+			return ErrorCodeMissingOauthParam, fmt.Sprintf("Missing required oauth parameter: %s", name)
+		}
+	}
+	if params.Get("oauth_signature_method") != oauth1.SignatureMethodHMACSHA1 {
+		return ErrorCodeInvalidSignatureType, "Unsupported oauth_signature_method"
+	}
+	if params.Get("oauth_consumer_key") != s.ConsumerKey {
+		return ErrorCodeInvalidConsumerKey, "Invalid consumer key"
+	}
+
+	// verify the signature against the consumer secret (no token secret)
+	if err := oauth1.Verify(r, s.ConsumerSecret, ""); err != nil {
+		return ErrorCodeInvalidSignature, "Invalid signature: oauth_signature does not match"
+	}
+	return 0, ""
+}
+
+// serveFoodSearch serves the 'foods.search' API method
+func (s *Server) serveFoodSearch(w http.ResponseWriter, params url.Values) {
+	query := params.Get("search_expression")
+	if query == "" {
+		writeError(w, params, ErrorCodeMissingParam, "Missing required parameter: search_expression")
+		return
+	}
+
+	// determine the requested page
+	page, size, ok := requestedPage(w, params)
+	if !ok {
+		return
+	}
+
+	// look up the results of the search
+	s.mu.Lock()
+	items := s.searches[strings.ToLower(query)]
+	s.mu.Unlock()
+
+	writeResponse(w, params, fatsecret.FoodSearchResponse{
+		Foods: &fatsecret.FoodSearchResponseFoods{
+			PageNumber:   page,
+			PageSize:     size,
+			TotalResults: len(items),
+			Food:         pageItems(items, page, size),
+		},
+	})
+}
+
+// serveFoodSearchV3 serves the 'foods.search.v3' API method, using the
+// food added for each search result's id
+func (s *Server) serveFoodSearchV3(w http.ResponseWriter, params url.Values) {
+	query := params.Get("search_expression")
+	if query == "" {
+		writeError(w, params, ErrorCodeMissingParam, "Missing required parameter: search_expression")
+		return
+	}
+
+	// determine the requested page
+	page, size, ok := requestedPage(w, params)
+	if !ok {
+		return
+	}
+
+	// look up the foods of the search
+	s.mu.Lock()
+	items := s.searches[strings.ToLower(query)]
+	foods := []fatsecret.FoodInfo{}
+	for _, item := range pageItems(items, page, size) {
+		food := toV4(searchFood(s.foods, item))
+
+		// only include the requested optional fields
+		if params.Get("include_sub_categories") != "true" {
+			food.SubCategories = nil
+		}
+		if params.Get("include_food_images") != "true" {
+			food.Images = nil
+		}
+		if params.Get("include_food_attributes") != "true" {
+			food.Attributes = nil
+		}
+		foods = append(foods, food)
+	}
+	s.mu.Unlock()
+
+	// an empty page has no results element
+	foodsSearch := &fatsecret.FoodSearchV3Foods{
+		PageNumber:   page,
+		PageSize:     size,
+		TotalResults: len(items),
+	}
+	if len(foods) > 0 {
+		foodsSearch.Results = &fatsecret.FoodSearchV3Results{Food: foods}
+	}
+	writeResponse(w, params, fatsecret.FoodSearchV3Response{Foods: foodsSearch})
+}
+
+// searchFood returns the food added for the search item's id, or else a
+// food built from the item's own fields
+func searchFood(foods map[string]fatsecret.FoodInfo, item fatsecret.FoodSearchItem) fatsecret.FoodInfo {
+	if food, ok := foods[item.ID]; ok {
+		return food
+	}
+	return fatsecret.FoodInfo{
+		ID:        item.ID,
+		Name:      item.Name,
+		Type:      item.Type,
+		URL:       item.URL,
+		BrandName: item.BrandName,
+	}
+}
+
+// serveFood serves the 'food.get' API methods. The fields added by
+// 'food.get.v4' are only served by v4.
+func (s *Server) serveFood(w http.ResponseWriter, params url.Values, v4 bool) {
+	s.mu.Lock()
+	food, ok := s.foods[params.Get("food_id")]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, params, ErrorCodeInvalidID, "Invalid ID: food_id")
+		return
+	}
+	if v4 {
+		food = toV4(food)
+	} else {
+		food = stripV4(food)
+	}
+	writeResponse(w, params, fatsecret.FoodInfoResponse{Food: &food})
+}
+
+// stripV4 returns a copy of the food without the 'food.get.v4' fields
+func stripV4(food fatsecret.FoodInfo) fatsecret.FoodInfo {
+	food.SubCategories = nil
+	food.Images = nil
+	food.Attributes = nil
+	servings := make([]fatsecret.FoodServing, len(food.Servings.Serving))
+	for i, serving := range food.Servings.Serving {
+		serving.AddedSugars = ""
+		serving.VitaminD = ""
+		serving.VitaminAMcg = ""
+		serving.VitaminCMg = ""
+		serving.CalciumMg = ""
+		serving.IronMg = ""
+		serving.IsDefault = ""
+		servings[i] = serving
+	}
+	food.Servings.Serving = servings
+	return food
+}
+
+// toV4 returns a copy of the food with the absolute vitamin and mineral
+// amounts of its servings in the API fields, as served by the v4 methods
+func toV4(food fatsecret.FoodInfo) fatsecret.FoodInfo {
+	servings := make([]fatsecret.FoodServing, len(food.Servings.Serving))
+	for i, serving := range food.Servings.Serving {
+		serving.VitaminA, serving.VitaminAMcg = serving.VitaminAMcg, ""
+		serving.VitaminC, serving.VitaminCMg = serving.VitaminCMg, ""
+		serving.Calcium, serving.CalciumMg = serving.CalciumMg, ""
+		serving.Iron, serving.IronMg = serving.IronMg, ""
+		servings[i] = serving
+	}
+	food.Servings.Serving = servings
+	return food
+}
+
+// serveBarcode serves the 'food.find_id_for_barcode' API method
+func (s *Server) serveBarcode(w http.ResponseWriter, params url.Values) {
+	barcode := params.Get("barcode")
+	if len(barcode) != 13 {
+		writeError(w, params, ErrorCodeMissingParam, "Invalid barcode: barcode must be GTIN-13")
+		return
+	}
+
+	// unknown barcodes return a food id of '0'
+	s.mu.Lock()
+	id, ok := s.barcodes[barcode]
+	s.mu.Unlock()
+	if !ok {
+		id = "0"
+	}
+	writeResponse(w, params, fatsecret.FoodIDResponse{ID: &fatsecret.FoodID{Value: id}})
+}
+
+// serveBrands serves the 'food_brands.get' API method
+func (s *Server) serveBrands(w http.ResponseWriter, params url.Values) {
+	// the brand type defaults to 'manufacturer'
+	brandType := fatsecret.BrandTypeManufacturer
+	if name := params.Get("brand_type"); name != "" {
+		var err error
+		if brandType, err = fatsecret.ParseBrandType(name); err != nil {
+			writeError(w, params, ErrorCodeMissingParam, "Invalid brand_type")
+			return
+		}
+	}
+
+	// filter the brands by starting character
+	s.mu.Lock()
+	brands := filterBrands(s.brands[brandType], params.Get("starts_with"))
+	s.mu.Unlock()
+
+	writeResponse(w, params, fatsecret.FoodBrandsResponse{Brands: &fatsecret.FoodBrands{Brands: brands}})
+}
+
+// serveCategories serves the 'food_categories.get' API method
+func (s *Server) serveCategories(w http.ResponseWriter, params url.Values) {
+	s.mu.Lock()
+	categories := append([]fatsecret.FoodCategory(nil), s.categories...)
+	s.mu.Unlock()
+	writeResponse(w, params, fatsecret.FoodCategoriesResponse{
+		Categories: &fatsecret.FoodCategories{Categories: categories},
+	})
+}
+
+// serveSubCategories serves the 'food_sub_categories.get' API method
+func (s *Server) serveSubCategories(w http.ResponseWriter, params url.Values) {
+	s.mu.Lock()
+	subs, ok := s.subCategories[params.Get("food_category_id")]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, params, ErrorCodeInvalidID, "Invalid ID: food_category_id")
+		return
+	}
+	writeResponse(w, params, fatsecret.FoodSubCategoriesResponse{
+		SubCategories: &fatsecret.FoodSubCategories{SubCategories: subs},
+	})
+}
+
+// filterBrands returns the brands with the given starting character,
+// where '*' matches brands starting with a digit
+func filterBrands(brands []string, startsWith string) []string {
+	startsWith = strings.ToLower(startsWith)
+	filtered := []string{}
+	for _, b := range brands {
+		lower := strings.ToLower(b)
+		switch {
+		case startsWith == "":
+		case startsWith == "*" && lower != "" && lower[0] >= '0' && lower[0] <= '9':
+		case startsWith != "*" && strings.HasPrefix(lower, startsWith):
+		default:
+			continue
+		}
+		filtered = append(filtered, b)
+	}
+	return filtered
+}
+
+// requestedPage returns the requested zero-based page and page size,
+// writing an error response when the page number is out of range
+func requestedPage(w http.ResponseWriter, params url.Values) (int, int, bool) {
+	page, _ := strconv.Atoi(params.Get("page_number"))
+	if page < 0 {
+		writeError(w, params, ErrorCodeValueOutOfRange, "Value out of range: page_number")
+		return 0, 0, false
+	}
+	size, _ := strconv.Atoi(params.Get("max_results"))
+	if size <= 0 {
+		size = 20
+	}
+	return page, size, true
+}
+
+// pageItems returns the zero-based page of the food items
+func pageItems(items []fatsecret.FoodSearchItem, page int, size int) []fatsecret.FoodSearchItem {
+	if page < 0 {
+		page = 0
+	}
+	start, end := page*size, (page+1)*size
+	if start > len(items) {
+		start = len(items)
+	}
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+// writeError writes a FatSecret error response in the requested format
+func writeError(w http.ResponseWriter, params url.Values, code int, message string) {
+	errResp := fatsecret.ErrorResponse{Code: code, Message: message}
+	if params.Get("format") == "xml" {
+		writeXML(w, errResp, "error")
+		return
+	}
+	writeJSON(w, struct {
+		Error fatsecret.ErrorResponse `json:"error"`
+	}{
+		Error: errResp,
+	})
+}
+
+// writeResponse writes the response in the requested format
+func writeResponse(w http.ResponseWriter, params url.Values, v interface{}) {
+	if params.Get("format") == "xml" {
+		writeXML(w, v, "")
+		return
+	}
+	writeJSON(w, v)
+}
+
+// writeJSON writes the value as a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeXML writes the value as an XML response, with the given root
+// element name unless the value encodes its own root
+func writeXML(w http.ResponseWriter, v interface{}, root string) {
+	w.Header().Set("Content-Type", "application/xml")
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	if root != "" {
+		enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: root}})
+		return
+	}
+	enc.Encode(v)
+}
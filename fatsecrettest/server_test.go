@@ -0,0 +1,124 @@
+package fatsecrettest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitzone/fatsecret"
+)
+
+func TestServerVerifiesSignature(t *testing.T) {
+	srv := NewServer("key", "secret")
+	defer srv.Close()
+	srv.AddCategory(fatsecret.FoodCategory{ID: "16", Name: "Desserts"})
+
+	// a client with the right secret is accepted
+	client, err := srv.NewClient()
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if _, err := client.FoodCategories(); err != nil {
+		t.Errorf("got '%v'; want the request to be accepted", err)
+	}
+
+	// a client with the wrong secret is rejected
+	bad, err := fatsecret.NewClient("key", "wrong", fatsecret.WithAPIURL(srv.URL))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if _, err := bad.FoodCategories(); err == nil {
+		t.Errorf("got no error; want an invalid signature error")
+	}
+
+	// both requests were recorded
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("got %d requests; want 2", got)
+	}
+}
+
+func TestServerFaults(t *testing.T) {
+	srv := NewServer("key", "secret")
+	defer srv.Close()
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+
+	// define the test-cases
+	testCases := []struct {
+		name   string
+		format fatsecret.Format
+		fault  Fault
+	}{
+		{"error code", fatsecret.FormatJSON, Fault{ErrorCode: 12, ErrorMessage: "User is performing too many actions"}},
+		{"server error", fatsecret.FormatJSON, Fault{Status: http.StatusServiceUnavailable}},
+		{"malformed json", fatsecret.FormatJSON, Fault{Malformed: true}},
+		{"malformed xml", fatsecret.FormatXML, Fault{Malformed: true}},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := srv.NewClient(fatsecret.WithFormat(tc.format))
+			if err != nil {
+				t.Fatalf("Could not create client: '%v'", err)
+			}
+			tc.fault.Count = 1
+			srv.InjectFault("food.get", tc.fault)
+
+			// the first request fails
+			if _, err := client.FoodByID("1"); err == nil {
+				t.Errorf("got no error; want the injected fault")
+			}
+
+			// the fault only applies once
+			if food, err := client.FoodByID("1"); err != nil || food.Name != "Apple" {
+				t.Errorf("got (%+v, '%v'); want the food", food, err)
+			}
+		})
+	}
+}
+
+func TestServerNegativePage(t *testing.T) {
+	srv := NewServer("key", "secret")
+	defer srv.Close()
+	srv.AddSearchResults("apple", fatsecret.FoodSearchItem{ID: "1", Name: "Apple"})
+
+	client, err := srv.NewClient()
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// a negative page is rejected with an api error, rather than a panic
+	for _, method := range []string{"foods.search", "foods.search.v3"} {
+		resp, err := client.InvokeAPIRaw(context.Background(), method, map[string]string{
+			"search_expression": "apple",
+			"page_number":       "-1",
+		})
+		if err != nil {
+			t.Fatalf("Could not invoke '%s': '%v'", method, err)
+		}
+		if !strings.Contains(string(resp.Body), "Value out of range") {
+			t.Errorf("got '%s'; want a value out of range error", resp.Body)
+		}
+	}
+}
+
+func TestServerLatency(t *testing.T) {
+	srv := NewServer("key", "secret")
+	defer srv.Close()
+	srv.InjectFault("", Fault{Latency: time.Second})
+
+	client, err := srv.NewClient()
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// the latency triggers the context deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.FoodByBarcode(ctx, "0748927052688"); err == nil {
+		t.Errorf("got no error; want a deadline error")
+	}
+}
@@ -1,29 +1,101 @@
 package fatsecret
 
 import (
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 const (
 	fatSecretBarcodeLength = 13
 )
 
+// ErrBarcodeNotFound is returned when the FatSecret API has no food
+// matching the given barcode
+var ErrBarcodeNotFound = errors.New("No food found for barcode")
+
+// FoodType is the enum type for the 'food_type' of a food
+type FoodType int
+
+const (
+	// FoodTypeUnknown is used when the food type is not given; when
+	// filtering searches it matches all food types
+	FoodTypeUnknown FoodType = iota
+	// FoodTypeGeneric is for the 'Generic' API food type
+	FoodTypeGeneric
+	// FoodTypeBrand is for the 'Brand' API food type
+	FoodTypeBrand
+)
+
+// String returns the API name of the food type (ie: "Generic")
+func (t FoodType) String() string {
+	switch t {
+	case FoodTypeUnknown:
+		return ""
+	case FoodTypeGeneric:
+		return "Generic"
+	case FoodTypeBrand:
+		return "Brand"
+	}
+	return fmt.Sprintf("FoodType(%d)", int(t))
+}
+
+// MarshalText encodes the food type as its API name
+func (t FoodType) MarshalText() ([]byte, error) {
+	if t < FoodTypeUnknown || t > FoodTypeBrand {
+		return nil, fmt.Errorf("Invalid food type '%d' given", int(t))
+	}
+	return []byte(t.String()), nil
+}
+
+// ParseFoodType converts the given API name (ie: "Generic") into the
+// associated food type. The name is not case sensitive.
+func ParseFoodType(name string) (FoodType, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		return FoodTypeUnknown, nil
+	case "generic":
+		return FoodTypeGeneric, nil
+	case "brand":
+		return FoodTypeBrand, nil
+	}
+	return FoodTypeUnknown, fmt.Errorf("Invalid food type '%s' given", name)
+}
+
+// UnmarshalText decodes the food type from its API name. Food types which
+// are not known are decoded as FoodTypeUnknown, so that a new API food
+// type does not fail the decoding of the whole response. Their API name
+// is not kept, so they are encoded back as an empty type.
+func (t *FoodType) UnmarshalText(text []byte) error {
+	*t, _ = ParseFoodType(string(text))
+	return nil
+}
+
+// FoodSearchOptions are the optional parameters of a food search
+type FoodSearchOptions struct {
+	PageNumber int      // the zero-based page of results to return
+	MaxResults int      // the maximum results per page (the API defaults to 20, up to 50)
+	Type       FoodType // only return foods of this type (FoodTypeUnknown returns all types)
+}
+
 type FoodSearchItem struct {
-	ID          string `json:"food_id,omitempty"`
-	Name        string `json:"food_name,omitempty"`
-	Type        string `json:"food_type,omitempty"`
-	BrandName   string `json:"brand_name,omitempty"`
-	URL         string `json:"food_url,omitempty"`
-	Description string `json:"food_description,omitempty"`
+	ID          string   `json:"food_id,omitempty" xml:"food_id"`
+	Name        string   `json:"food_name,omitempty" xml:"food_name"`
+	Type        FoodType `json:"food_type,omitempty" xml:"food_type"`
+	BrandName   string   `json:"brand_name,omitempty" xml:"brand_name"`
+	URL         string   `json:"food_url,omitempty" xml:"food_url"`
+	Description string   `json:"food_description,omitempty" xml:"food_description"`
 }
 
 type FoodSearchResponseFoods struct {
-	PageNumber   int              `json:"page_number,string"`
-	PageSize     int              `json:"max_results,string"`
-	TotalResults int              `json:"total_results,string"`
-	Food         []FoodSearchItem `json:"food"`
+	PageNumber   int              `json:"page_number,string" xml:"page_number"`
+	PageSize     int              `json:"max_results,string" xml:"max_results"`
+	TotalResults int              `json:"total_results,string" xml:"total_results"`
+	Food         []FoodSearchItem `json:"food" xml:"food"`
 }
 
 type FoodSearchResponse struct {
@@ -31,8 +103,39 @@ type FoodSearchResponse struct {
 	Error *ErrorResponse           `json:"error,omitempty"`
 }
 
+// UnmarshalJSON handles the API returning a single food object, rather
+// than an array, when a search has one result
+func (f *FoodSearchResponseFoods) UnmarshalJSON(data []byte) error {
+	// decode the other fields and defer decoding of the food value
+	type foods FoodSearchResponseFoods
+	raw := struct {
+		*foods
+		Food json.RawMessage `json:"food"`
+	}{foods: (*foods)(f)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	f.Food = nil
+	return unmarshalJSONArray(raw.Food, &f.Food)
+}
+
+// UnmarshalXML decodes either a '<foods>' or an '<error>' response
+func (r *FoodSearchResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if isXMLError(start) {
+		r.Error = &ErrorResponse{}
+		return d.DecodeElement(r.Error, &start)
+	}
+	r.Foods = &FoodSearchResponseFoods{}
+	return d.DecodeElement(r.Foods, &start)
+}
+
+// MarshalXML encodes either a '<foods>' or an '<error>' response
+func (r FoodSearchResponse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalXMLResponse(e, r.Error, "foods", r.Foods)
+}
+
 type FoodID struct {
-	Value string `json:"value"`
+	Value string `json:"value" xml:"value"`
 }
 
 type FoodIDResponse struct {
@@ -40,47 +143,94 @@ type FoodIDResponse struct {
 	Error *ErrorResponse `json:"error,omitempty"`
 }
 
+// UnmarshalXML decodes either a '<food_id>' or an '<error>' response
+func (r *FoodIDResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if isXMLError(start) {
+		r.Error = &ErrorResponse{}
+		return d.DecodeElement(r.Error, &start)
+	}
+	r.ID = &FoodID{}
+	return d.DecodeElement(r.ID, &start)
+}
+
+// MarshalXML encodes either a '<food_id>' or an '<error>' response
+func (r FoodIDResponse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalXMLResponse(e, r.Error, "food_id", r.ID)
+}
+
 type FoodInfo struct {
-	ID        string       `json:"food_id"`
-	Name      string       `json:"food_name"`
-	Type      string       `json:"food_type"`
-	URL       string       `json:"food_url"`
-	BrandName string       `json:"brand_name"`
-	Servings  FoodServings `json:"servings"`
+	ID        string       `json:"food_id" xml:"food_id"`
+	Name      string       `json:"food_name" xml:"food_name"`
+	Type      FoodType     `json:"food_type" xml:"food_type"`
+	URL       string       `json:"food_url" xml:"food_url"`
+	BrandName string       `json:"brand_name" xml:"brand_name"`
+	Servings  FoodServings `json:"servings" xml:"servings"`
+
+	// the optional fields returned by FoodByIDV4
+	SubCategories *FoodSubCategories `json:"food_sub_categories,omitempty" xml:"food_sub_categories,omitempty"`
+	Images        *FoodImages        `json:"food_images,omitempty" xml:"food_images,omitempty"`
+	Attributes    *FoodAttributes    `json:"food_attributes,omitempty" xml:"food_attributes,omitempty"`
 }
 
+// FoodServings are the servings of a food. Serving is a slice, rather than
+// the single serving of earlier releases, since most foods have several.
 type FoodServings struct {
-	Serving FoodServing `json:"serving"`
+	Serving []FoodServing `json:"serving" xml:"serving"`
+}
+
+// UnmarshalJSON handles the API returning a single serving object,
+// rather than an array, when a food only has one serving
+func (s *FoodServings) UnmarshalJSON(data []byte) error {
+	// defer decoding of the serving value
+	raw := struct {
+		Serving json.RawMessage `json:"serving"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	s.Serving = nil
+	return unmarshalJSONArray(raw.Serving, &s.Serving)
 }
 
 type FoodServing struct {
 	// serving info
-	ServingID              string `json:"serving_id"`              // serving_id – the unique serving identifier.
-	ServingDescription     string `json:"serving_description"`     // serving_description – the full description of the serving size. E.G.: "1 cup" or "100 g".
-	ServingURL             string `json:"serving_url"`             // serving_url – URL of the serving size for this food item on www.fatsecret.com.
-	MetricServingAmount    string `json:"metric_serving_amount"`   // metric_serving_amount is a Decimal - the metric quantity combined with metric_serving_unit to derive the total standardized quantity of the serving (where available).
-	MetricServingUnit      string `json:"metric_serving_unit"`     // metric_serving_unit – the metric unit of measure for the serving size – either "g" or "ml" or "oz" – combined with metric_serving_amount to derive the total standardized quantity of the serving (where available).
-	NumberOfUnits          string `json:"number_of_units"`         // number_of_units is a Decimal - the number of units in this standard serving size. For instance, if the serving description is "2 tablespoons" the number of units is "2", while if the serving size is "1 cup" the number of units is "1".
-	MeasurementDescription string `json:"measurement_description"` // measurement_description – a description of the unit of measure used in the serving description. For instance, if the description is "1/2 cup" the measurement description is "cup", while if the serving size is "100 g" the measurement description is "g".
+	ServingID              string `json:"serving_id" xml:"serving_id"`                           // serving_id – the unique serving identifier.
+	ServingDescription     string `json:"serving_description" xml:"serving_description"`         // serving_description – the full description of the serving size. E.G.: "1 cup" or "100 g".
+	ServingURL             string `json:"serving_url" xml:"serving_url"`                         // serving_url – URL of the serving size for this food item on www.fatsecret.com.
+	MetricServingAmount    string `json:"metric_serving_amount" xml:"metric_serving_amount"`     // metric_serving_amount is a Decimal - the metric quantity combined with metric_serving_unit to derive the total standardized quantity of the serving (where available).
+	MetricServingUnit      string `json:"metric_serving_unit" xml:"metric_serving_unit"`         // metric_serving_unit – the metric unit of measure for the serving size – either "g" or "ml" or "oz" – combined with metric_serving_amount to derive the total standardized quantity of the serving (where available).
+	NumberOfUnits          string `json:"number_of_units" xml:"number_of_units"`                 // number_of_units is a Decimal - the number of units in this standard serving size. For instance, if the serving description is "2 tablespoons" the number of units is "2", while if the serving size is "1 cup" the number of units is "1".
+	MeasurementDescription string `json:"measurement_description" xml:"measurement_description"` // measurement_description – a description of the unit of measure used in the serving description. For instance, if the description is "1/2 cup" the measurement description is "cup", while if the serving size is "100 g" the measurement description is "g".
 
 	// nutrient info
-	Calories           string `json:"calories"`            // calories is a Decimal – the energy content in kcal.
-	Carbohydrate       string `json:"carbohydrate"`        // carbohydrate is a Decimal – the total carbohydrate content in grams.
-	Protein            string `json:"protein"`             // protein is a Decimal – the protein content in grams.
-	Fat                string `json:"fat"`                 // fat is a Decimal – the total fat content in grams.
-	SaturatedFat       string `json:"saturated_fat"`       // saturated_fat is a Decimal – the saturated fat content in grams (where available).
-	PolyunsaturatedFat string `json:"polyunsaturated_fat"` // polyunsaturated_fat is a Decimal – the polyunsaturated fat content in grams (where available).
-	MonounsaturatedFat string `json:"monounsaturated_fat"` // monounsaturated_fat is a Decimal – the monounsaturated fat content in grams (where available).
-	TransFat           string `json:"trans_fat"`           // trans_fat is a Decimal – the trans fat content in grams (where available).
-	Cholesterol        string `json:"cholesterol"`         // cholesterol is a Decimal – the cholesterol content in milligrams (where available).
-	Sodium             string `json:"sodium"`              // sodium is a Decimal – the sodium content in milligrams (where available).
-	Potassium          string `json:"potassium"`           // potassium is a Decimal – the potassium content in milligrams (where available).
-	Fiber              string `json:"fiber"`               // fiber is a Decimal – the fiber content in grams (where available).
-	Sugar              string `json:"sugar"`               // sugar is a Decimal – the sugar content in grams (where available).
-	VitaminA           string `json:"vitamin_a"`           // vitamin_a is a Decimal – the percentage of daily recommended Vitamin A, based on a 2000 calorie diet (where available).
-	VitaminC           string `json:"vitamin_c"`           // vitamin_c is a Decimal – the percentage of daily recommended Vitamin C, based on a 2000 calorie diet (where available).
-	Calcium            string `json:"calcium"`             // calcium is a Decimal – the percentage of daily recommended Calcium, based on a 2000 calorie diet (where available).
-	Iron               string `json:"iron"`                // iron is a Decimal – the percentage of daily recommended Iron, based on a 2000 calorie diet (where available).
+	Calories           string `json:"calories" xml:"calories"`                       // calories is a Decimal – the energy content in kcal.
+	Carbohydrate       string `json:"carbohydrate" xml:"carbohydrate"`               // carbohydrate is a Decimal – the total carbohydrate content in grams.
+	Protein            string `json:"protein" xml:"protein"`                         // protein is a Decimal – the protein content in grams.
+	Fat                string `json:"fat" xml:"fat"`                                 // fat is a Decimal – the total fat content in grams.
+	SaturatedFat       string `json:"saturated_fat" xml:"saturated_fat"`             // saturated_fat is a Decimal – the saturated fat content in grams (where available).
+	PolyunsaturatedFat string `json:"polyunsaturated_fat" xml:"polyunsaturated_fat"` // polyunsaturated_fat is a Decimal – the polyunsaturated fat content in grams (where available).
+	MonounsaturatedFat string `json:"monounsaturated_fat" xml:"monounsaturated_fat"` // monounsaturated_fat is a Decimal – the monounsaturated fat content in grams (where available).
+	TransFat           string `json:"trans_fat" xml:"trans_fat"`                     // trans_fat is a Decimal – the trans fat content in grams (where available).
+	Cholesterol        string `json:"cholesterol" xml:"cholesterol"`                 // cholesterol is a Decimal – the cholesterol content in milligrams (where available).
+	Sodium             string `json:"sodium" xml:"sodium"`                           // sodium is a Decimal – the sodium content in milligrams (where available).
+	Potassium          string `json:"potassium" xml:"potassium"`                     // potassium is a Decimal – the potassium content in milligrams (where available).
+	Fiber              string `json:"fiber" xml:"fiber"`                             // fiber is a Decimal – the fiber content in grams (where available).
+	Sugar              string `json:"sugar" xml:"sugar"`                             // sugar is a Decimal – the sugar content in grams (where available).
+	VitaminA           string `json:"vitamin_a" xml:"vitamin_a"`                     // vitamin_a is a Decimal – the percentage of daily recommended Vitamin A, based on a 2000 calorie diet (where available).
+	VitaminC           string `json:"vitamin_c" xml:"vitamin_c"`                     // vitamin_c is a Decimal – the percentage of daily recommended Vitamin C, based on a 2000 calorie diet (where available).
+	Calcium            string `json:"calcium" xml:"calcium"`                         // calcium is a Decimal – the percentage of daily recommended Calcium, based on a 2000 calorie diet (where available).
+	Iron               string `json:"iron" xml:"iron"`                               // iron is a Decimal – the percentage of daily recommended Iron, based on a 2000 calorie diet (where available).
+
+	// food.get.v4 info. The v4 methods return absolute amounts for the
+	// vitamin and mineral values above, which are moved into the fields
+	// below so they are never mistaken for percentages.
+	AddedSugars string `json:"added_sugars,omitempty" xml:"added_sugars,omitempty"`   // added_sugars is a Decimal – the added sugars content in grams (where available).
+	VitaminD    string `json:"vitamin_d,omitempty" xml:"vitamin_d,omitempty"`         // vitamin_d is a Decimal – the vitamin D content in micrograms (where available).
+	VitaminAMcg string `json:"vitamin_a_mcg,omitempty" xml:"vitamin_a_mcg,omitempty"` // the v4 vitamin_a Decimal – the vitamin A content in micrograms (where available).
+	VitaminCMg  string `json:"vitamin_c_mg,omitempty" xml:"vitamin_c_mg,omitempty"`   // the v4 vitamin_c Decimal – the vitamin C content in milligrams (where available).
+	CalciumMg   string `json:"calcium_mg,omitempty" xml:"calcium_mg,omitempty"`       // the v4 calcium Decimal – the calcium content in milligrams (where available).
+	IronMg      string `json:"iron_mg,omitempty" xml:"iron_mg,omitempty"`             // the v4 iron Decimal – the iron content in milligrams (where available).
+	IsDefault   string `json:"is_default,omitempty" xml:"is_default,omitempty"`       // is_default is "1" for the default serving of the food.
 }
 
 type FoodInfoResponse struct {
@@ -88,23 +238,49 @@ type FoodInfoResponse struct {
 	Error *ErrorResponse `json:"error,omitempty"`
 }
 
+// UnmarshalXML decodes either a '<food>' or an '<error>' response
+func (r *FoodInfoResponse) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if isXMLError(start) {
+		r.Error = &ErrorResponse{}
+		return d.DecodeElement(r.Error, &start)
+	}
+	r.Food = &FoodInfo{}
+	return d.DecodeElement(r.Food, &start)
+}
+
+// MarshalXML encodes either a '<food>' or an '<error>' response
+func (r FoodInfoResponse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalXMLResponse(e, r.Error, "food", r.Food)
+}
+
 // FoodSearch invokes the FatSecret 'foods.search' API call and
 // returns the response as a slice of FoodSearchItem structs
 func (c *Client) FoodSearch(query string) ([]FoodSearchItem, error) {
-	// invoke the api call
-	body, err := c.InvokeAPI(
-		"foods.search",
-		map[string]string{
-			"search_expression": query,
-		},
-	)
-	if err != nil {
-		return nil, err
+	return c.FoodSearchWithOptions(context.Background(), query, FoodSearchOptions{})
+}
+
+// FoodSearchWithOptions invokes the FatSecret 'foods.search' API call using
+// the given paging options. The API cannot filter by food type, so the
+// type filter is applied to the returned page, which may leave it short.
+func (c *Client) FoodSearchWithOptions(ctx context.Context, query string, opts FoodSearchOptions) (items []FoodSearchItem, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "FoodSearch", StringAttribute(AttrQuery, query))
+	defer func() { c.endSpan(span, err) }()
+
+	// build the api parameters
+	params := map[string]string{
+		"search_expression": query,
+	}
+	if opts.PageNumber > 0 {
+		params["page_number"] = strconv.Itoa(opts.PageNumber)
+	}
+	if opts.MaxResults > 0 {
+		params["max_results"] = strconv.Itoa(opts.MaxResults)
 	}
 
-	// parse the api response
+	// invoke the api call, decoding the response
 	foodResp := FoodSearchResponse{}
-	if err := json.Unmarshal(body, &foodResp); err != nil {
+	if err := c.invokeDecode(ctx, "foods.search", params, &foodResp); err != nil {
 		return nil, err
 	}
 
@@ -114,36 +290,59 @@ func (c *Client) FoodSearch(query string) ([]FoodSearchItem, error) {
 		return nil, errors.New(foodResp.Error.Message)
 	}
 
-	// return the slice of food items
-	return foodResp.Foods.Food, nil
+	// if no foods were found
+	if foodResp.Foods == nil {
+		return nil, nil
+	}
+
+	// return the slice of food items of the requested type
+	return FilterFoodsByType(foodResp.Foods.Food, opts.Type), nil
+}
+
+// FilterFoodsByType returns the food items of the given type. All of the
+// items are returned for FoodTypeUnknown.
+func FilterFoodsByType(items []FoodSearchItem, t FoodType) []FoodSearchItem {
+	if t == FoodTypeUnknown {
+		return items
+	}
+	filtered := []FoodSearchItem{}
+	for _, item := range items {
+		if item.Type == t {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
 }
 
 // FoodIDForBarcode invokes the FatSecret 'food.find_id_for_barcode' API call and
 // returns the response as a slice of Food structs
 func (c *Client) FoodIDForBarcode(barcode string) (string, error) {
-	// if the barcode is invalid
-	barcodeLen := len(barcode)
-	if barcodeLen == 0 || barcodeLen > fatSecretBarcodeLength {
-		return "", fmt.Errorf("Invalid barcode length '%d' given", barcodeLen)
-	}
+	return c.foodIDForBarcode(context.Background(), barcode)
+}
 
-	// pad the barcode to GTIN-13 format
-	barcode = padLeft(barcode, fatSecretBarcodeLength, "0")
+// foodIDForBarcode invokes the 'food.find_id_for_barcode' API call
+// bound to the given context
+func (c *Client) foodIDForBarcode(ctx context.Context, barcode string) (id string, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "FoodIDForBarcode", StringAttribute(AttrBarcode, barcode))
+	defer func() { c.endSpan(span, err) }()
 
-	// invoke the api call
-	body, err := c.InvokeAPI(
-		"food.find_id_for_barcode",
-		map[string]string{
-			"barcode": barcode,
-		},
-	)
+	// normalize the barcode to GTIN-13 format
+	barcode, err = NormalizeBarcode(barcode)
 	if err != nil {
 		return "", err
 	}
 
-	// parse the api response
+	// invoke the api call, decoding the response
 	foodIDResp := FoodIDResponse{}
-	if err := json.Unmarshal(body, &foodIDResp); err != nil {
+	if err := c.invokeDecode(
+		ctx,
+		"food.find_id_for_barcode",
+		map[string]string{
+			"barcode": barcode,
+		},
+		&foodIDResp,
+	); err != nil {
 		return "", err
 	}
 
@@ -153,32 +352,65 @@ func (c *Client) FoodIDForBarcode(barcode string) (string, error) {
 		return "", errors.New(foodIDResp.Error.Message)
 	}
 
+	// the api returns no id (or an id of '0') for unknown barcodes
+	if foodIDResp.ID == nil {
+		return "", nil
+	}
+
 	// return the slice of food items
 	return foodIDResp.ID.Value, nil
 }
 
+// FoodByBarcode finds the food id for the given barcode and then
+// fetches the detailed food info for it. ErrBarcodeNotFound is
+// returned when the barcode does not match any food.
+func (c *Client) FoodByBarcode(ctx context.Context, barcode string) (food *FoodInfo, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "FoodByBarcode", StringAttribute(AttrBarcode, barcode))
+	defer func() { c.endSpan(span, err) }()
+
+	// find the food id for the barcode
+	id, err := c.foodIDForBarcode(ctx, barcode)
+	if err != nil {
+		return nil, err
+	}
+
+	// the api returns a food id of '0' when there is no match
+	if id == "" || id == "0" {
+		return nil, ErrBarcodeNotFound
+	}
+
+	// fetch the food info for the id
+	return c.foodByID(ctx, id)
+}
+
 // FoodByID invokes the FatSecret 'food.get' API call for the
 // given food-id and returns the response
 func (c *Client) FoodByID(id string) (*FoodInfo, error) {
+	return c.foodByID(context.Background(), id)
+}
+
+// foodByID invokes the 'food.get' API call bound to the given context
+func (c *Client) foodByID(ctx context.Context, id string) (food *FoodInfo, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "FoodByID", StringAttribute(AttrFoodID, id))
+	defer func() { c.endSpan(span, err) }()
+
 	// if the food id is invalid
 	if len(id) == 0 {
 		return nil, fmt.Errorf("Invalid food id '%s' given", id)
 	}
 
 	// invoke the api call
-	body, err := c.InvokeAPI(
-		"food.get",
-		map[string]string{
-			"food_id": id,
-		},
-	)
-	if err != nil {
-		return nil, err
-	}
+	return c.getFood(ctx, "food.get", map[string]string{"food_id": id})
+}
 
-	// parse the api response
+// getFood invokes the given version of the 'food.get' API call and
+// returns the food info
+func (c *Client) getFood(ctx context.Context, apiMethod string, params map[string]string) (*FoodInfo, error) {
+	// invoke the api call, decoding the response
 	resp := FoodInfoResponse{}
-	if err := json.Unmarshal(body, &resp); err != nil {
+	if err := c.invokeDecode(ctx, apiMethod, params, &resp); err != nil {
 		return nil, err
 	}
 
@@ -191,3 +423,37 @@ func (c *Client) FoodByID(id string) (*FoodInfo, error) {
 	// return the food info
 	return resp.Food, nil
 }
+
+// NormalizeBarcode strips separators from the given barcode, validates
+// it and pads it to the GTIN-13 format expected by the API (ie: a UPC-A
+// "748927052688" becomes "0748927052688")
+func NormalizeBarcode(barcode string) (string, error) {
+	// remove any spaces or dashes (ie: "0 74892 70526 8")
+	barcode = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, barcode)
+
+	// the barcode must only contain digits
+	for _, r := range barcode {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("Invalid barcode '%s' given", barcode)
+		}
+	}
+
+	// a GTIN-14 with a zero packaging indicator is a GTIN-13
+	if len(barcode) == fatSecretBarcodeLength+1 && barcode[0] == '0' {
+		barcode = barcode[1:]
+	}
+
+	// if the barcode length is invalid
+	barcodeLen := len(barcode)
+	if barcodeLen == 0 || barcodeLen > fatSecretBarcodeLength {
+		return "", fmt.Errorf("Invalid barcode length '%d' given", barcodeLen)
+	}
+
+	// pad the barcode to GTIN-13 format (ie: UPC-A and EAN-8)
+	return padLeft(barcode, fatSecretBarcodeLength, "0"), nil
+}
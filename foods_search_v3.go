@@ -0,0 +1,181 @@
+package fatsecret
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// FoodFilter selects foods by their type, brand and attributes. The API
+// cannot filter search results, so the filter is applied to the returned
+// page, which may leave it short. The zero value matches every food.
+type FoodFilter struct {
+	Type             FoodType // only foods of this type (FoodTypeUnknown matches all types)
+	Brand            string   // only foods of this brand, not case sensitive
+	ExcludeAllergens []string // exclude foods which contain any of these allergens (ie: AllergenMilk)
+	Preferences      []string // only foods which suit all of these preferences (ie: PreferenceVegan)
+}
+
+// Match reports whether the food passes the filter. Allergens and
+// preferences are only known when the food includes its attributes, so
+// a food with unknown allergens is kept while one with an unknown
+// preference is excluded.
+func (f FoodFilter) Match(food *FoodInfo) bool {
+	// if the food is not of the requested type or brand
+	if f.Type != FoodTypeUnknown && food.Type != f.Type {
+		return false
+	}
+	if f.Brand != "" && !strings.EqualFold(food.BrandName, f.Brand) {
+		return false
+	}
+
+	// if the food contains an excluded allergen
+	for _, name := range f.ExcludeAllergens {
+		if food.Allergen(name) == AttributeYes {
+			return false
+		}
+	}
+
+	// if the food does not suit a preference
+	for _, name := range f.Preferences {
+		if food.Preference(name) != AttributeYes {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the foods which pass the filter
+func (f FoodFilter) Apply(foods []FoodInfo) []FoodInfo {
+	filtered := []FoodInfo{}
+	for i := range foods {
+		if f.Match(&foods[i]) {
+			filtered = append(filtered, foods[i])
+		}
+	}
+	return filtered
+}
+
+// needsAttributes reports whether the filter uses the food attributes
+func (f FoodFilter) needsAttributes() bool {
+	return len(f.ExcludeAllergens) > 0 || len(f.Preferences) > 0
+}
+
+// FoodSearchV3Options are the optional parameters of a 'foods.search.v3'
+// food search
+type FoodSearchV3Options struct {
+	PageNumber int        // the zero-based page of results to return
+	MaxResults int        // the maximum results per page (the API defaults to 20, up to 50)
+	Filter     FoodFilter // the filter applied to the returned page
+
+	// the optional fields of the returned foods. The attributes are
+	// always included when the filter uses allergens or preferences.
+	IncludeSubCategories bool
+	IncludeImages        bool
+	IncludeAttributes    bool
+}
+
+// FoodSearchV3Results are the foods of a 'foods.search.v3' response
+type FoodSearchV3Results struct {
+	Food []FoodInfo `json:"food" xml:"food"`
+}
+
+// UnmarshalJSON handles the API returning a single food object, rather
+// than an array, when a search has one result
+func (r *FoodSearchV3Results) UnmarshalJSON(data []byte) error {
+	raw := struct {
+		Food json.RawMessage `json:"food"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	r.Food = nil
+	return unmarshalJSONArray(raw.Food, &r.Food)
+}
+
+type FoodSearchV3Foods struct {
+	PageNumber   int                  `json:"page_number,string" xml:"page_number"`
+	PageSize     int                  `json:"max_results,string" xml:"max_results"`
+	TotalResults int                  `json:"total_results,string" xml:"total_results"`
+	Results      *FoodSearchV3Results `json:"results,omitempty" xml:"results,omitempty"`
+}
+
+type FoodSearchV3Response struct {
+	Foods *FoodSearchV3Foods `json:"foods_search,omitempty"`
+	Error *ErrorResponse     `json:"error,omitempty"`
+}
+
+// UnmarshalXML decodes either a '<foods_search>' or an '<error>' response
+func (r *FoodSearchV3Response) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if isXMLError(start) {
+		r.Error = &ErrorResponse{}
+		return d.DecodeElement(r.Error, &start)
+	}
+	r.Foods = &FoodSearchV3Foods{}
+	return d.DecodeElement(r.Foods, &start)
+}
+
+// MarshalXML encodes either a '<foods_search>' or an '<error>' response
+func (r FoodSearchV3Response) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return marshalXMLResponse(e, r.Error, "foods_search", r.Foods)
+}
+
+// FoodSearchV3 invokes the FatSecret 'foods.search.v3' API call, which
+// embeds the servings and nutrition of each food in the results, so no
+// follow-up FoodByID is needed. The default serving of each food is
+// flagged and the filter of the options is applied to the returned page.
+// The servings hold the absolute amounts of FoodByIDV4.
+func (c *Client) FoodSearchV3(ctx context.Context, query string, opts FoodSearchV3Options) (foods []FoodInfo, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "FoodSearchV3", StringAttribute(AttrQuery, query))
+	defer func() { c.endSpan(span, err) }()
+
+	// build the api parameters
+	params := map[string]string{
+		"search_expression":    query,
+		"flag_default_serving": "true",
+	}
+	if opts.PageNumber > 0 {
+		params["page_number"] = strconv.Itoa(opts.PageNumber)
+	}
+	if opts.MaxResults > 0 {
+		params["max_results"] = strconv.Itoa(opts.MaxResults)
+	}
+	if opts.IncludeSubCategories {
+		params["include_sub_categories"] = "true"
+	}
+	if opts.IncludeImages {
+		params["include_food_images"] = "true"
+	}
+	if opts.IncludeAttributes || opts.Filter.needsAttributes() {
+		params["include_food_attributes"] = "true"
+	}
+
+	// invoke the api call, decoding the response
+	foodResp := FoodSearchV3Response{}
+	if err := c.invokeDecode(ctx, "foods.search.v3", params, &foodResp); err != nil {
+		return nil, err
+	}
+
+	// if an error response was returned
+	if foodResp.Error != nil {
+		// return the response error message
+		return nil, errors.New(foodResp.Error.Message)
+	}
+
+	// if no foods were found
+	if foodResp.Foods == nil || foodResp.Foods.Results == nil {
+		return nil, nil
+	}
+
+	// return the foods which pass the filter, with the absolute amounts
+	// of their servings moved like FoodByIDV4
+	foods = foodResp.Foods.Results.Food
+	for i := range foods {
+		foods[i].moveAbsoluteAmounts()
+	}
+	return opts.Filter.Apply(foods), nil
+}
@@ -0,0 +1,171 @@
+package fatsecret
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeBarcode(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		barcode string
+		want    string
+		valid   bool
+	}{
+		{"0748927052688", "0748927052688", true},  // EAN-13
+		{"748927052688", "0748927052688", true},   // UPC-A
+		{"96385074", "0000096385074", true},       // EAN-8
+		{"00748927052688", "0748927052688", true}, // GTIN-14 with zero indicator
+		{"0 748927 052688", "0748927052688", true},
+		{"074-8927-052688", "0748927052688", true},
+		{"10748927052688", "", false},  // GTIN-14 with non-zero indicator
+		{"074892705268800", "", false}, // too long
+		{"", "", false},
+		{"07489x7052688", "", false},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(fmt.Sprintf("Barcode '%s'", tc.barcode), func(t *testing.T) {
+			got, err := NormalizeBarcode(tc.barcode)
+			if !tc.valid {
+				if err == nil {
+					t.Errorf("got '%s'; want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: '%v'", err)
+			}
+			if got != tc.want {
+				t.Errorf("got '%s'; want '%s'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFoodServingsUnmarshal(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		name  string
+		json  string
+		count int
+	}{
+		{"single serving object", `{"serving": {"serving_id": "1"}}`, 1},
+		{"array of servings", `{"serving": [{"serving_id": "1"}, {"serving_id": "2"}]}`, 2},
+		{"no servings", `{}`, 0},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			servings := FoodServings{}
+			if err := json.Unmarshal([]byte(tc.json), &servings); err != nil {
+				t.Fatalf("Could not parse servings: '%v'", err)
+			}
+			if len(servings.Serving) != tc.count {
+				t.Errorf("got %d servings; want %d", len(servings.Serving), tc.count)
+			}
+		})
+	}
+}
+
+func TestFoodTypeJSON(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		json     string
+		foodType FoodType
+		encoded  string
+	}{
+		{`{"food_type": "Generic"}`, FoodTypeGeneric, `{"food_type":"Generic"}`},
+		{`{"food_type": "Brand"}`, FoodTypeBrand, `{"food_type":"Brand"}`},
+		{`{}`, FoodTypeUnknown, `{}`},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.json, func(t *testing.T) {
+			// decode the food type
+			item := FoodSearchItem{}
+			if err := json.Unmarshal([]byte(tc.json), &item); err != nil {
+				t.Fatalf("Could not parse food type: '%v'", err)
+			}
+			if item.Type != tc.foodType {
+				t.Errorf("got '%v'; want '%v'", item.Type, tc.foodType)
+			}
+
+			// encode the food type back to json
+			out, err := json.Marshal(item)
+			if err != nil {
+				t.Fatalf("Could not encode food type: '%v'", err)
+			}
+			if string(out) != tc.encoded {
+				t.Errorf("got '%s'; want '%s'", out, tc.encoded)
+			}
+		})
+	}
+
+	// unknown food types are decoded as unknown
+	item := FoodSearchItem{}
+	if err := json.Unmarshal([]byte(`{"food_id": "1", "food_type": "Restaurant"}`), &item); err != nil {
+		t.Fatalf("Could not parse unknown food type: '%v'", err)
+	}
+	if item.ID != "1" || item.Type != FoodTypeUnknown {
+		t.Errorf("got '%v' '%v'; want '1' '%v'", item.ID, item.Type, FoodTypeUnknown)
+	}
+}
+
+func TestParseFoodType(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		name     string
+		foodType FoodType
+		valid    bool
+	}{
+		{"Generic", FoodTypeGeneric, true},
+		{" brand ", FoodTypeBrand, true},
+		{"", FoodTypeUnknown, true},
+		{"Restaurant", FoodTypeUnknown, false},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseFoodType(tc.name)
+			if (err == nil) != tc.valid {
+				t.Fatalf("got error '%v'; want valid '%v'", err, tc.valid)
+			}
+			if got != tc.foodType {
+				t.Errorf("got '%v'; want '%v'", got, tc.foodType)
+			}
+		})
+	}
+}
+
+func TestFilterFoodsByType(t *testing.T) {
+	items := []FoodSearchItem{
+		{ID: "1", Type: FoodTypeGeneric},
+		{ID: "2", Type: FoodTypeBrand},
+		{ID: "3", Type: FoodTypeGeneric},
+	}
+
+	// filter the generic foods
+	if got := FilterFoodsByType(items, FoodTypeGeneric); len(got) != 2 {
+		t.Errorf("got %d generic foods; want 2", len(got))
+	}
+
+	// filter the brand foods
+	if got := FilterFoodsByType(items, FoodTypeBrand); len(got) != 1 || got[0].ID != "2" {
+		t.Errorf("got %+v; want food '2'", got)
+	}
+
+	// unknown type returns all of the foods
+	if got := FilterFoodsByType(items, FoodTypeUnknown); len(got) != 3 {
+		t.Errorf("got %d foods; want 3", len(got))
+	}
+}
@@ -0,0 +1,244 @@
+package fatsecret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// the API names of the food allergens
+const (
+	AllergenEgg       = "Egg"
+	AllergenFish      = "Fish"
+	AllergenGluten    = "Gluten"
+	AllergenLactose   = "Lactose"
+	AllergenMilk      = "Milk"
+	AllergenNuts      = "Nuts"
+	AllergenPeanuts   = "Peanuts"
+	AllergenSesame    = "Sesame"
+	AllergenShellfish = "Shellfish"
+	AllergenSoy       = "Soy"
+)
+
+// the API names of the dietary preferences
+const (
+	PreferenceVegan      = "Vegan"
+	PreferenceVegetarian = "Vegetarian"
+)
+
+// AttributeValue is the enum type for the value of a food allergen or
+// dietary preference
+type AttributeValue int
+
+const (
+	// AttributeUnknown is used when the API does not know the value ('-1')
+	AttributeUnknown AttributeValue = iota
+	// AttributeNo is used when the food does not have the attribute ('0')
+	AttributeNo
+	// AttributeYes is used when the food has the attribute ('1')
+	AttributeYes
+)
+
+// String returns the API value of the attribute (ie: "1")
+func (v AttributeValue) String() string {
+	switch v {
+	case AttributeUnknown:
+		return "-1"
+	case AttributeNo:
+		return "0"
+	case AttributeYes:
+		return "1"
+	}
+	return fmt.Sprintf("AttributeValue(%d)", int(v))
+}
+
+// MarshalText encodes the attribute value as its API value
+func (v AttributeValue) MarshalText() ([]byte, error) {
+	if v < AttributeUnknown || v > AttributeYes {
+		return nil, fmt.Errorf("Invalid attribute value '%d' given", int(v))
+	}
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText decodes the attribute value from its API value
+func (v *AttributeValue) UnmarshalText(text []byte) error {
+	switch strings.TrimSpace(string(text)) {
+	case "-1", "":
+		*v = AttributeUnknown
+	case "0":
+		*v = AttributeNo
+	case "1":
+		*v = AttributeYes
+	default:
+		return fmt.Errorf("Invalid attribute value '%s' given", text)
+	}
+	return nil
+}
+
+// FoodAttribute is an allergen or a dietary preference of a food
+type FoodAttribute struct {
+	ID    string         `json:"id" xml:"id"`
+	Name  string         `json:"name" xml:"name"`
+	Value AttributeValue `json:"value" xml:"value"`
+}
+
+// FoodAllergens are the allergens of a food
+type FoodAllergens struct {
+	Allergen []FoodAttribute `json:"allergen" xml:"allergen"`
+}
+
+// UnmarshalJSON handles the API returning a single allergen object,
+// rather than an array
+func (a *FoodAllergens) UnmarshalJSON(data []byte) error {
+	raw := struct {
+		Allergen json.RawMessage `json:"allergen"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	a.Allergen = nil
+	return unmarshalJSONArray(raw.Allergen, &a.Allergen)
+}
+
+// FoodPreferences are the dietary preferences a food is suitable for
+type FoodPreferences struct {
+	Preference []FoodAttribute `json:"preference" xml:"preference"`
+}
+
+// UnmarshalJSON handles the API returning a single preference object,
+// rather than an array
+func (p *FoodPreferences) UnmarshalJSON(data []byte) error {
+	raw := struct {
+		Preference json.RawMessage `json:"preference"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	p.Preference = nil
+	return unmarshalJSONArray(raw.Preference, &p.Preference)
+}
+
+// FoodAttributes are the allergens and dietary preferences of a food
+type FoodAttributes struct {
+	Allergens   *FoodAllergens   `json:"allergens,omitempty" xml:"allergens,omitempty"`
+	Preferences *FoodPreferences `json:"preferences,omitempty" xml:"preferences,omitempty"`
+}
+
+// FoodImage is an image of a food
+type FoodImage struct {
+	URL  string `json:"image_url" xml:"image_url"`
+	Type string `json:"image_type" xml:"image_type"`
+}
+
+// FoodImages are the images of a food
+type FoodImages struct {
+	Image []FoodImage `json:"food_image" xml:"food_image"`
+}
+
+// UnmarshalJSON handles the API returning a single image object,
+// rather than an array
+func (i *FoodImages) UnmarshalJSON(data []byte) error {
+	raw := struct {
+		Image json.RawMessage `json:"food_image"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	i.Image = nil
+	return unmarshalJSONArray(raw.Image, &i.Image)
+}
+
+// Allergen returns whether the food contains the named allergen (ie:
+// AllergenMilk), which is unknown when it was not returned
+func (f *FoodInfo) Allergen(name string) AttributeValue {
+	if f.Attributes == nil || f.Attributes.Allergens == nil {
+		return AttributeUnknown
+	}
+	return attributeValue(f.Attributes.Allergens.Allergen, name)
+}
+
+// Preference returns whether the food suits the named dietary preference
+// (ie: PreferenceVegan), which is unknown when it was not returned
+func (f *FoodInfo) Preference(name string) AttributeValue {
+	if f.Attributes == nil || f.Attributes.Preferences == nil {
+		return AttributeUnknown
+	}
+	return attributeValue(f.Attributes.Preferences.Preference, name)
+}
+
+// DefaultServing returns the default serving of the food, as flagged by
+// FoodByIDV4, or nil
+func (f *FoodInfo) DefaultServing() *FoodServing {
+	for i := range f.Servings.Serving {
+		if f.Servings.Serving[i].IsDefault == "1" {
+			return &f.Servings.Serving[i]
+		}
+	}
+	return nil
+}
+
+// moveAbsoluteAmounts moves the vitamin A, vitamin C, calcium and iron
+// values of servings returned by the v4 methods, which are absolute
+// amounts, out of the percentage fields used by the older methods
+func (f *FoodInfo) moveAbsoluteAmounts() {
+	for i := range f.Servings.Serving {
+		s := &f.Servings.Serving[i]
+		if s.VitaminA != "" {
+			s.VitaminAMcg, s.VitaminA = s.VitaminA, ""
+		}
+		if s.VitaminC != "" {
+			s.VitaminCMg, s.VitaminC = s.VitaminC, ""
+		}
+		if s.Calcium != "" {
+			s.CalciumMg, s.Calcium = s.Calcium, ""
+		}
+		if s.Iron != "" {
+			s.IronMg, s.Iron = s.Iron, ""
+		}
+	}
+}
+
+// attributeValue returns the value of the named attribute
+func attributeValue(attrs []FoodAttribute, name string) AttributeValue {
+	for _, attr := range attrs {
+		if strings.EqualFold(attr.Name, name) {
+			return attr.Value
+		}
+	}
+	return AttributeUnknown
+}
+
+// FoodByIDV4 invokes the FatSecret 'food.get.v4' API call for the given
+// food-id. Along with the FoodByID info, it returns the food's images,
+// allergens, dietary preferences and sub-categories, flags the default
+// serving and adds the added sugars and vitamin D of the servings. The
+// vitamin A, vitamin C, calcium and iron of its servings are absolute
+// amounts, so they are returned in the VitaminAMcg, VitaminCMg, CalciumMg
+// and IronMg fields rather than the percentage fields.
+func (c *Client) FoodByIDV4(ctx context.Context, id string) (food *FoodInfo, err error) {
+	// trace the call
+	ctx, span := c.startCall(ctx, "FoodByIDV4", StringAttribute(AttrFoodID, id))
+	defer func() { c.endSpan(span, err) }()
+
+	// if the food id is invalid
+	if len(id) == 0 {
+		return nil, fmt.Errorf("Invalid food id '%s' given", id)
+	}
+
+	// invoke the api call, including all of the optional fields
+	food, err = c.getFood(ctx, "food.get.v4", map[string]string{
+		"food_id":                 id,
+		"include_sub_categories":  "true",
+		"include_food_images":     "true",
+		"include_food_attributes": "true",
+		"flag_default_serving":    "true",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if food != nil {
+		food.moveAbsoluteAmounts()
+	}
+	return food, nil
+}
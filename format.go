@@ -0,0 +1,93 @@
+package fatsecret
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+)
+
+// Format is the enum type for the response format requested from the API
+type Format int
+
+const (
+	// FormatJSON requests JSON responses (the default)
+	FormatJSON Format = iota
+	// FormatXML requests XML responses
+	FormatXML
+)
+
+// String returns the API name of the format (ie: "json")
+func (f Format) String() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatXML:
+		return "xml"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// WithFormat sets the response format requested from the API. The typed
+// API methods return the same values whichever format is used.
+func WithFormat(format Format) Option {
+	return func(c *Client) {
+		c.format = format
+	}
+}
+
+// isXMLError reports whether the XML response root is an error response
+func isXMLError(start xml.StartElement) bool {
+	return start.Name.Local == "error"
+}
+
+// marshalXMLResponse encodes an XML response as either the error
+// response or the named payload element
+func marshalXMLResponse(e *xml.Encoder, errResp *ErrorResponse, name string, payload interface{}) error {
+	if errResp != nil {
+		return e.EncodeElement(errResp, xml.StartElement{Name: xml.Name{Local: "error"}})
+	}
+	return e.EncodeElement(payload, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+// unmarshalJSONArray decodes a JSON array into the slice pointed to by v.
+// The API returns a single object, rather than an array, when there is
+// only one element, so a single value is decoded as a one-element slice.
+func unmarshalJSONArray(data json.RawMessage, v interface{}) error {
+	// if no elements were returned
+	value := bytes.TrimSpace(data)
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		return nil
+	}
+
+	// wrap a single value into an array
+	if value[0] != '[' {
+		value = append(append([]byte("["), value...), ']')
+	}
+	return json.Unmarshal(value, v)
+}
+
+// responseError returns the FatSecret error code and message of the
+// response body, if it is an error response
+func responseError(format Format, body []byte) (int, string) {
+	// an XML error response has an '<error>' root
+	if format == FormatXML {
+		root := struct {
+			XMLName xml.Name
+			ErrorResponse
+		}{}
+		if err := xml.Unmarshal(body, &root); err != nil || !isXMLError(xml.StartElement{Name: root.XMLName}) {
+			return 0, ""
+		}
+		return root.Code, root.Message
+	}
+
+	// a JSON error response has an 'error' member
+	resp := struct {
+		Error *ErrorResponse `json:"error"`
+	}{}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error == nil {
+		return 0, ""
+	}
+	return resp.Error.Code, resp.Error.Message
+}
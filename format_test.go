@@ -0,0 +1,300 @@
+package fatsecret
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSingleObjectJSON(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		name string
+		json string
+		got  interface{}
+		want interface{}
+	}{
+		{
+			"single search result",
+			`{"page_number": "0", "max_results": "20", "total_results": "1", "food": {"food_id": "1", "food_type": "Brand"}}`,
+			&FoodSearchResponseFoods{},
+			&FoodSearchResponseFoods{PageSize: 20, TotalResults: 1, Food: []FoodSearchItem{{ID: "1", Type: FoodTypeBrand}}},
+		},
+		{
+			"search results",
+			`{"page_number": "1", "max_results": "2", "total_results": "4", "food": [{"food_id": "1"}, {"food_id": "2"}]}`,
+			&FoodSearchResponseFoods{},
+			&FoodSearchResponseFoods{PageNumber: 1, PageSize: 2, TotalResults: 4, Food: []FoodSearchItem{{ID: "1"}, {ID: "2"}}},
+		},
+		{
+			"single brand",
+			`{"food_brand": "Acme"}`,
+			&FoodBrands{},
+			&FoodBrands{Brands: []string{"Acme"}},
+		},
+		{
+			"single category",
+			`{"food_category": {"food_category_id": "1", "food_category_name": "Fruit"}}`,
+			&FoodCategories{},
+			&FoodCategories{Categories: []FoodCategory{{ID: "1", Name: "Fruit"}}},
+		},
+		{
+			"single sub-category",
+			`{"food_sub_category": "Apples"}`,
+			&FoodSubCategories{},
+			&FoodSubCategories{SubCategories: []string{"Apples"}},
+		},
+		{
+			"no sub-categories",
+			`{}`,
+			&FoodSubCategories{},
+			&FoodSubCategories{},
+		},
+		{
+			"single v3 search result",
+			`{"food": {"food_id": "1", "servings": {"serving": {"serving_id": "10"}}}}`,
+			&FoodSearchV3Results{},
+			&FoodSearchV3Results{Food: []FoodInfo{{ID: "1", Servings: FoodServings{Serving: []FoodServing{{ServingID: "10"}}}}}},
+		},
+		{
+			"single image",
+			`{"food_image": {"image_url": "https://example.com/1.jpg", "image_type": "0"}}`,
+			&FoodImages{},
+			&FoodImages{Image: []FoodImage{{URL: "https://example.com/1.jpg", Type: "0"}}},
+		},
+		{
+			"single allergen",
+			`{"allergen": {"id": "1", "name": "Milk", "value": "-1"}}`,
+			&FoodAllergens{},
+			&FoodAllergens{Allergen: []FoodAttribute{{ID: "1", Name: "Milk", Value: AttributeUnknown}}},
+		},
+		{
+			"single preference",
+			`{"preference": {"id": "2", "name": "Vegetarian", "value": "1"}}`,
+			&FoodPreferences{},
+			&FoodPreferences{Preference: []FoodAttribute{{ID: "2", Name: "Vegetarian", Value: AttributeYes}}},
+		},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			if err := json.Unmarshal([]byte(tc.json), tc.got); err != nil {
+				t.Fatalf("Could not parse json: '%v'", err)
+			}
+			if !reflect.DeepEqual(tc.got, tc.want) {
+				t.Errorf("got '%+v'; want '%+v'", tc.got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResponseXML(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		name string
+		xml  string
+		got  interface{}
+		want interface{}
+	}{
+		{
+			"food",
+			`<?xml version="1.0" encoding="utf-8" ?>
+			<food xmlns="http://platform.fatsecret.com/api/1.0/">
+				<food_id>1</food_id><food_name>Apple</food_name><food_type>Generic</food_type>
+				<servings><serving><serving_id>10</serving_id><calories>52</calories></serving></servings>
+			</food>`,
+			&FoodInfoResponse{},
+			&FoodInfoResponse{Food: &FoodInfo{
+				ID:       "1",
+				Name:     "Apple",
+				Type:     FoodTypeGeneric,
+				Servings: FoodServings{Serving: []FoodServing{{ServingID: "10", Calories: "52"}}},
+			}},
+		},
+		{
+			"search",
+			`<foods><max_results>20</max_results><total_results>1</total_results><page_number>0</page_number>
+			<food><food_id>1</food_id><food_type>Brand</food_type></food></foods>`,
+			&FoodSearchResponse{},
+			&FoodSearchResponse{Foods: &FoodSearchResponseFoods{
+				PageSize:     20,
+				TotalResults: 1,
+				Food:         []FoodSearchItem{{ID: "1", Type: FoodTypeBrand}},
+			}},
+		},
+		{
+			"barcode",
+			`<food_id><value>42</value></food_id>`,
+			&FoodIDResponse{},
+			&FoodIDResponse{ID: &FoodID{Value: "42"}},
+		},
+		{
+			"brands",
+			`<food_brands><food_brand>Acme</food_brand><food_brand>Bolt</food_brand></food_brands>`,
+			&FoodBrandsResponse{},
+			&FoodBrandsResponse{Brands: &FoodBrands{Brands: []string{"Acme", "Bolt"}}},
+		},
+		{
+			"categories",
+			`<food_categories><food_category><food_category_id>1</food_category_id><food_category_name>Fruit</food_category_name></food_category></food_categories>`,
+			&FoodCategoriesResponse{},
+			&FoodCategoriesResponse{Categories: &FoodCategories{Categories: []FoodCategory{{ID: "1", Name: "Fruit"}}}},
+		},
+		{
+			"sub-categories",
+			`<food_sub_categories><food_sub_category>Apples</food_sub_category></food_sub_categories>`,
+			&FoodSubCategoriesResponse{},
+			&FoodSubCategoriesResponse{SubCategories: &FoodSubCategories{SubCategories: []string{"Apples"}}},
+		},
+		{
+			"error",
+			`<error><code>106</code><message>Invalid ID: food_id</message></error>`,
+			&FoodInfoResponse{},
+			&FoodInfoResponse{Error: &ErrorResponse{Code: 106, Message: "Invalid ID: food_id"}},
+		},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			if err := xml.Unmarshal([]byte(tc.xml), tc.got); err != nil {
+				t.Fatalf("Could not parse xml: '%v'", err)
+			}
+			if !reflect.DeepEqual(tc.got, tc.want) {
+				t.Errorf("got '%+v'; want '%+v'", tc.got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResponseXMLFixtures(t *testing.T) {
+	apples := FoodServing{
+		ServingID:              "34280",
+		ServingDescription:     "1 cup quartered or chopped",
+		ServingURL:             "https://www.fatsecret.com/calories-nutrition/usda/apples?portionid=34280&portionamount=1.000",
+		MetricServingAmount:    "125.000",
+		MetricServingUnit:      "g",
+		NumberOfUnits:          "1.000",
+		MeasurementDescription: "cup, quartered or chopped",
+		Calories:               "65",
+		Carbohydrate:           "17.26",
+		Protein:                "0.33",
+		Fat:                    "0.21",
+		SaturatedFat:           "0.035",
+		PolyunsaturatedFat:     "0.064",
+		MonounsaturatedFat:     "0.009",
+		Cholesterol:            "0",
+		Sodium:                 "1",
+		Potassium:              "134",
+		Fiber:                  "3.0",
+		Sugar:                  "12.99",
+		VitaminA:               "1",
+		VitaminC:               "8",
+		Calcium:                "1",
+		Iron:                   "1",
+	}
+
+	// define the test-cases
+	testCases := []struct {
+		file  string
+		got   interface{}
+		check func(t *testing.T, got interface{})
+	}{
+		{
+			"food.get.xml",
+			&FoodInfoResponse{},
+			func(t *testing.T, got interface{}) {
+				food := got.(*FoodInfoResponse).Food
+				if food == nil || food.ID != "33691" || food.Type != FoodTypeGeneric ||
+					food.URL != "https://www.fatsecret.com/calories-nutrition/usda/apples" {
+					t.Fatalf("got food '%+v'; want the apples", food)
+				}
+				if len(food.Servings.Serving) != 2 {
+					t.Fatalf("got %d servings; want 2", len(food.Servings.Serving))
+				}
+				if !reflect.DeepEqual(food.Servings.Serving[0], apples) {
+					t.Errorf("got serving '%+v'; want '%+v'", food.Servings.Serving[0], apples)
+				}
+				if got := food.Servings.Serving[1].ServingDescription; got != "100 g" {
+					t.Errorf("got serving '%s'; want '100 g'", got)
+				}
+			},
+		},
+		{
+			"foods.search.xml",
+			&FoodSearchResponse{},
+			func(t *testing.T, got interface{}) {
+				want := &FoodSearchResponseFoods{
+					PageNumber:   0,
+					PageSize:     2,
+					TotalResults: 1472,
+					Food: []FoodSearchItem{
+						{
+							ID:          "33691",
+							Name:        "Apples",
+							Type:        FoodTypeGeneric,
+							URL:         "https://www.fatsecret.com/calories-nutrition/usda/apples",
+							Description: "Per 100g - Calories: 52kcal | Fat: 0.17g | Carbs: 13.81g | Protein: 0.26g",
+						},
+						{
+							ID:          "4881224",
+							Name:        "Apple Slices",
+							Type:        FoodTypeBrand,
+							BrandName:   "Chiquita",
+							URL:         "https://www.fatsecret.com/calories-nutrition/chiquita/apple-slices",
+							Description: "Per 1 bag - Calories: 25kcal | Fat: 0.00g | Carbs: 6.00g | Protein: 0.00g",
+						},
+					},
+				}
+				if foods := got.(*FoodSearchResponse).Foods; !reflect.DeepEqual(foods, want) {
+					t.Errorf("got '%+v'; want '%+v'", foods, want)
+				}
+			},
+		},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.file, func(t *testing.T) {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatalf("Could not read fixture: '%v'", err)
+			}
+			if err := xml.Unmarshal(data, tc.got); err != nil {
+				t.Fatalf("Could not parse xml: '%v'", err)
+			}
+			tc.check(t, tc.got)
+		})
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		format  Format
+		body    string
+		code    int
+		message string
+	}{
+		{FormatJSON, `{"error": {"code": 106, "message": "Invalid ID"}}`, 106, "Invalid ID"},
+		{FormatJSON, `{"food": {"food_id": "1"}}`, 0, ""},
+		{FormatXML, `<error><code>106</code><message>Invalid ID</message></error>`, 106, "Invalid ID"},
+		{FormatXML, `<food><food_id>1</food_id></food>`, 0, ""},
+		{FormatXML, `not xml`, 0, ""},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		code, message := responseError(tc.format, []byte(tc.body))
+		if code != tc.code || message != tc.message {
+			t.Errorf("got %d '%s' for '%s'; want %d '%s'", code, message, tc.body, tc.code, tc.message)
+		}
+	}
+}
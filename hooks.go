@@ -0,0 +1,221 @@
+package fatsecret
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// the value which replaces redacted parameters
+	redacted = "REDACTED"
+)
+
+// redactedParams are the oauth parameters which are never logged or
+// passed to hooks
+var redactedParams = map[string]bool{
+	"oauth_consumer_key": true,
+	"oauth_signature":    true,
+	"oauth_token":        true,
+}
+
+// redactedPattern matches a redacted oauth parameter and its value in the
+// text of a response (ie: "oauth_signature 'abc='" or "oauth_signature=abc%3D")
+var redactedPattern = regexp.MustCompile(`(oauth_(?:consumer_key|signature|token)(?:\s*[=:]\s*['"]?|\s*['"]))([^'"&\s<>,;()]+)`)
+
+// RequestInfo describes an API request which is about to be sent
+type RequestInfo struct {
+	Method string            // the API method (ie: "food.get")
+	Params map[string]string // the request parameters, with the oauth credentials redacted
+	URL    string            // the request URL, with the oauth credentials redacted
+}
+
+// ResponseInfo describes the outcome of an API request
+type ResponseInfo struct {
+	Method       string            // the API method (ie: "food.get")
+	Params       map[string]string // the request parameters, with the oauth credentials redacted
+	Latency      time.Duration     // the time taken by the http request
+	StatusCode   int               // the http status code, or zero if no response was received
+	Size         int               // the size of the response body in bytes
+	ErrorCode    int               // the FatSecret error code, or zero
+	ErrorMessage string            // the FatSecret error message, if any, with the oauth credentials redacted
+	Err          error             // the transport error, if any
+}
+
+// WithLogger sets the structured logger used to record every API call.
+// Successful calls are logged at debug level and failed calls at warn level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithDebug enables logging the request URL and the response body of
+// every API call. The oauth credentials and signatures are always redacted.
+func WithDebug(debug bool) Option {
+	return func(c *Client) {
+		c.debug = debug
+	}
+}
+
+// WithRequestHook sets a callback which is invoked before each API request
+func WithRequestHook(hook func(RequestInfo)) Option {
+	return func(c *Client) {
+		c.requestHook = hook
+	}
+}
+
+// WithResponseHook sets a callback which is invoked after each API request
+func WithResponseHook(hook func(ResponseInfo)) Option {
+	return func(c *Client) {
+		c.responseHook = hook
+	}
+}
+
+// observing reports whether any logger, hook or metrics needs the call details
+func (c *Client) observing() bool {
+	return c.logger != nil || c.requestHook != nil || c.responseHook != nil || c.metrics != nil
+}
+
+// onRequest invokes the request hook
+func (c *Client) onRequest(info RequestInfo) {
+	if c.requestHook != nil {
+		c.requestHook(info)
+	}
+}
+
+// onResponse invokes the response hook, records the metrics and logs the API call
+func (c *Client) onResponse(ctx context.Context, req RequestInfo, info ResponseInfo, body string) {
+	if c.responseHook != nil {
+		c.responseHook(info)
+	}
+	if c.metrics != nil {
+		c.metrics.ObserveResponse(info)
+	}
+	if c.logger == nil {
+		return
+	}
+
+	// build the log attributes
+	attrs := []slog.Attr{
+		slog.String("method", info.Method),
+		slog.Any("params", info.Params),
+		slog.Duration("latency", info.Latency),
+		slog.Int("status", info.StatusCode),
+		slog.Int("size", info.Size),
+	}
+	if info.ErrorCode != 0 {
+		attrs = append(attrs,
+			slog.Int("error_code", info.ErrorCode),
+			slog.String("error_message", info.ErrorMessage),
+		)
+	}
+	if info.Err != nil {
+		attrs = append(attrs, slog.String("error", info.Err.Error()))
+	}
+	if c.debug {
+		attrs = append(attrs,
+			slog.String("url", req.URL),
+			slog.String("body", body),
+		)
+	}
+
+	// failed calls are logged as warnings
+	level := slog.LevelDebug
+	if info.Err != nil || info.ErrorCode != 0 || info.StatusCode >= 300 {
+		level = slog.LevelWarn
+	}
+	c.logger.LogAttrs(ctx, level, "fatsecret api call", attrs...)
+}
+
+// requestInfo builds the redacted description of the signed API URL
+func requestInfo(apiMethod string, apiURL string) RequestInfo {
+	info := RequestInfo{
+		Method: apiMethod,
+		Params: map[string]string{},
+	}
+
+	// redact the oauth credentials from the url
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return info
+	}
+	query := u.Query()
+	for k := range query {
+		if redactedParams[k] {
+			query.Set(k, redacted)
+		}
+		info.Params[k] = query.Get(k)
+	}
+	u.RawQuery = query.Encode()
+	info.URL = u.String()
+
+	return info
+}
+
+// redactSecrets redacts the consumer key and the oauth credentials of the
+// signed API URL from the text, such as an API error message which echoes
+// the signature (ie: "Invalid signature: oauth_signature '...'")
+func (c *Client) redactSecrets(apiURL string, text string) string {
+	// redact the values of the oauth parameters
+	secrets := []string{c.consumerKey, url.QueryEscape(c.consumerKey)}
+	if u, err := url.Parse(apiURL); err == nil {
+		query := u.Query()
+		for k := range redactedParams {
+			if v := query.Get(k); v != "" && v != redacted {
+				secrets = append(secrets, v, url.QueryEscape(v))
+			}
+		}
+	}
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+
+	// redact any other values given for the oauth parameters
+	return redactedPattern.ReplaceAllString(text, "${1}"+redacted)
+}
+
+// redactError returns the error with the oauth credentials of the signed
+// API URL redacted from its message. A *url.Error keeps its type, with
+// its URL redacted, so that it can still be detected as a transport error.
+func (c *Client) redactError(apiURL string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// redact the url of a transport error
+	if urlErr, ok := err.(*url.Error); ok {
+		redactedErr := *urlErr
+		redactedErr.URL = c.redactSecrets(apiURL, urlErr.URL)
+		redactedErr.Err = c.redactError(apiURL, urlErr.Err)
+		return &redactedErr
+	}
+
+	// wrap any other error which contains the credentials
+	text := c.redactSecrets(apiURL, err.Error())
+	if text == err.Error() {
+		return err
+	}
+	return &redactedError{text: text, err: err}
+}
+
+// redactedError is an error whose message has its credentials redacted
+type redactedError struct {
+	text string
+	err  error
+}
+
+// Error returns the redacted error message
+func (e *redactedError) Error() string {
+	return e.text
+}
+
+// Unwrap returns the original error
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
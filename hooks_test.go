@@ -0,0 +1,177 @@
+package fatsecret_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/fatsecrettest"
+)
+
+func TestLoggingAndHooks(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+
+	// create a client which logs and records the hook calls
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	requests := []fatsecret.RequestInfo{}
+	responses := []fatsecret.ResponseInfo{}
+	client, err := srv.NewClient(
+		fatsecret.WithLogger(logger),
+		fatsecret.WithDebug(true),
+		fatsecret.WithRequestHook(func(info fatsecret.RequestInfo) { requests = append(requests, info) }),
+		fatsecret.WithResponseHook(func(info fatsecret.ResponseInfo) { responses = append(responses, info) }),
+	)
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// a successful call and a call returning a FatSecret error
+	if _, err := client.FoodByID("1"); err != nil {
+		t.Fatalf("Could not fetch food: '%v'", err)
+	}
+	if _, err := client.FoodByID("2"); err == nil {
+		t.Fatalf("got no error; want an invalid id error")
+	}
+
+	// verify the hook calls
+	if len(requests) != 2 || len(responses) != 2 {
+		t.Fatalf("got %d requests and %d responses; want 2 of each", len(requests), len(responses))
+	}
+	if requests[0].Method != "food.get" || requests[0].Params["food_id"] != "1" {
+		t.Errorf("got request %+v; want the food.get request", requests[0])
+	}
+	if responses[0].StatusCode != 200 || responses[0].Size == 0 || responses[0].ErrorCode != 0 {
+		t.Errorf("got response %+v; want a successful response", responses[0])
+	}
+	if responses[1].ErrorCode != fatsecrettest.ErrorCodeInvalidID {
+		t.Errorf("got error code %d; want %d", responses[1].ErrorCode, fatsecrettest.ErrorCodeInvalidID)
+	}
+
+	// the credentials and signature are redacted everywhere
+	if got := requests[0].Params["oauth_consumer_key"]; got != "REDACTED" {
+		t.Errorf("got consumer key '%s'; want it redacted", got)
+	}
+	if got := requests[0].Params["oauth_signature"]; got != "REDACTED" {
+		t.Errorf("got signature '%s'; want it redacted", got)
+	}
+	if strings.Contains(logs.String(), "test-key") || strings.Contains(requests[0].URL, "test-key") {
+		t.Errorf("got logs '%s'; want the consumer key redacted", logs)
+	}
+	if !strings.Contains(requests[0].URL, "oauth_signature=REDACTED") {
+		t.Errorf("got url '%s'; want the signature redacted", requests[0].URL)
+	}
+
+	// the debug logs contain the body, and the failed call is a warning
+	if !strings.Contains(logs.String(), "Apple") {
+		t.Errorf("got logs '%s'; want the response body", logs)
+	}
+	if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), "error_code=106") {
+		t.Errorf("got logs '%s'; want a warning with the error code", logs)
+	}
+}
+
+func TestLoggingRedactsBadSignature(t *testing.T) {
+	// an api which echoes the signature in its error, like the live api
+	mu := sync.Mutex{}
+	signatures := []string{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		mu.Lock()
+		signatures = append(signatures, params.Get("oauth_signature"))
+		mu.Unlock()
+		fmt.Fprintf(w, `{"error": {"code": 8, "message": "Invalid signature: oauth_signature '%s' (oauth_consumer_key=%s)"}}`,
+			params.Get("oauth_signature"), params.Get("oauth_consumer_key"))
+	}))
+	defer api.Close()
+
+	// iterate through the debug modes
+	for _, debug := range []bool{false, true} {
+		// run the next sub-test
+		t.Run(fmt.Sprintf("debug %v", debug), func(t *testing.T) {
+			logs := &bytes.Buffer{}
+			logger := slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			responses := []fatsecret.ResponseInfo{}
+			client, err := fatsecret.NewClient("secret-key", "wrong-secret",
+				fatsecret.WithAPIURL(api.URL),
+				fatsecret.WithLogger(logger),
+				fatsecret.WithDebug(debug),
+				fatsecret.WithResponseHook(func(info fatsecret.ResponseInfo) { responses = append(responses, info) }),
+			)
+			if err != nil {
+				t.Fatalf("Could not create client: '%v'", err)
+			}
+			if _, err := client.FoodByID("1"); err == nil {
+				t.Fatalf("got no error; want an invalid signature error")
+			}
+
+			// the signature and consumer key are redacted from the hook and logs
+			mu.Lock()
+			signature := signatures[len(signatures)-1]
+			mu.Unlock()
+			if len(responses) != 1 || responses[0].ErrorCode != 8 {
+				t.Fatalf("got responses %+v; want the invalid signature error", responses)
+			}
+			if got := responses[0].ErrorMessage; got != "Invalid signature: oauth_signature 'REDACTED' (oauth_consumer_key=REDACTED)" {
+				t.Errorf("got error message '%s'; want the signature redacted", got)
+			}
+			for _, secret := range []string{signature, "secret-key"} {
+				if strings.Contains(logs.String(), secret) {
+					t.Errorf("got logs '%s'; want '%s' redacted", logs, secret)
+				}
+			}
+			if !strings.Contains(logs.String(), "oauth_signature 'REDACTED'") {
+				t.Errorf("got logs '%s'; want the redacted error message", logs)
+			}
+		})
+	}
+}
+
+func TestHooksRedactTransportError(t *testing.T) {
+	// a transport which fails, so that the error contains the signed url
+	signature := ""
+	failing := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		signature = req.URL.Query().Get("oauth_signature")
+		return nil, errors.New("connection refused")
+	})
+
+	responses := []fatsecret.ResponseInfo{}
+	client, err := fatsecret.NewClient("secret-key", "secret",
+		fatsecret.WithTransport(failing),
+		fatsecret.WithResponseHook(func(info fatsecret.ResponseInfo) { responses = append(responses, info) }),
+	)
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	_, err = client.FoodByID("1")
+	if err == nil {
+		t.Fatalf("got no error; want the transport error")
+	}
+
+	// the signature and consumer key are redacted from the hook and the
+	// returned error, which is still a transport error
+	if len(responses) != 1 || responses[0].Err == nil {
+		t.Fatalf("got responses %+v; want the transport error", responses)
+	}
+	for _, text := range []string{responses[0].Err.Error(), err.Error()} {
+		for _, secret := range []string{signature, "secret-key"} {
+			if strings.Contains(text, secret) {
+				t.Errorf("got error '%s'; want '%s' redacted", text, secret)
+			}
+		}
+	}
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Errorf("got '%T'; want a *url.Error", err)
+	}
+}
@@ -0,0 +1,259 @@
+package fatsecret
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Region is a FatSecret region code (ie: "US"), which localizes the
+// foods and servings returned by the API
+type Region string
+
+// the regions supported by the API
+const (
+	RegionArgentina          Region = "AR"
+	RegionAustralia          Region = "AU"
+	RegionAustria            Region = "AT"
+	RegionBelgium            Region = "BE"
+	RegionBrazil             Region = "BR"
+	RegionCanada             Region = "CA"
+	RegionChile              Region = "CL"
+	RegionChina              Region = "CN"
+	RegionColombia           Region = "CO"
+	RegionCzechRepublic      Region = "CZ"
+	RegionDenmark            Region = "DK"
+	RegionFinland            Region = "FI"
+	RegionFrance             Region = "FR"
+	RegionGermany            Region = "DE"
+	RegionGreece             Region = "GR"
+	RegionHongKong           Region = "HK"
+	RegionHungary            Region = "HU"
+	RegionIndia              Region = "IN"
+	RegionIndonesia          Region = "ID"
+	RegionIreland            Region = "IE"
+	RegionIsrael             Region = "IL"
+	RegionItaly              Region = "IT"
+	RegionJapan              Region = "JP"
+	RegionKorea              Region = "KR"
+	RegionMalaysia           Region = "MY"
+	RegionMexico             Region = "MX"
+	RegionNetherlands        Region = "NL"
+	RegionNewZealand         Region = "NZ"
+	RegionNorway             Region = "NO"
+	RegionPeru               Region = "PE"
+	RegionPhilippines        Region = "PH"
+	RegionPoland             Region = "PL"
+	RegionPortugal           Region = "PT"
+	RegionRomania            Region = "RO"
+	RegionRussia             Region = "RU"
+	RegionSaudiArabia        Region = "SA"
+	RegionSingapore          Region = "SG"
+	RegionSlovakia           Region = "SK"
+	RegionSouthAfrica        Region = "ZA"
+	RegionSpain              Region = "ES"
+	RegionSweden             Region = "SE"
+	RegionSwitzerland        Region = "CH"
+	RegionTaiwan             Region = "TW"
+	RegionThailand           Region = "TH"
+	RegionTurkey             Region = "TR"
+	RegionUnitedArabEmirates Region = "AE"
+	RegionUnitedKingdom      Region = "GB"
+	RegionUnitedStates       Region = "US"
+	RegionVietnam            Region = "VN"
+)
+
+// Regions are all of the regions supported by the API
+var Regions = []Region{
+	RegionArgentina, RegionAustralia, RegionAustria, RegionBelgium, RegionBrazil,
+	RegionCanada, RegionChile, RegionChina, RegionColombia, RegionCzechRepublic,
+	RegionDenmark, RegionFinland, RegionFrance, RegionGermany, RegionGreece,
+	RegionHongKong, RegionHungary, RegionIndia, RegionIndonesia, RegionIreland,
+	RegionIsrael, RegionItaly, RegionJapan, RegionKorea, RegionMalaysia,
+	RegionMexico, RegionNetherlands, RegionNewZealand, RegionNorway, RegionPeru,
+	RegionPhilippines, RegionPoland, RegionPortugal, RegionRomania, RegionRussia,
+	RegionSaudiArabia, RegionSingapore, RegionSlovakia, RegionSouthAfrica, RegionSpain,
+	RegionSweden, RegionSwitzerland, RegionTaiwan, RegionThailand, RegionTurkey,
+	RegionUnitedArabEmirates, RegionUnitedKingdom, RegionUnitedStates, RegionVietnam,
+}
+
+// Language is a FatSecret language code (ie: "fr"), which translates the
+// foods and servings of a region
+type Language string
+
+// the languages supported by the API
+const (
+	LanguageArabic     Language = "ar"
+	LanguageChinese    Language = "zh"
+	LanguageCzech      Language = "cs"
+	LanguageDanish     Language = "da"
+	LanguageDutch      Language = "nl"
+	LanguageEnglish    Language = "en"
+	LanguageFinnish    Language = "fi"
+	LanguageFrench     Language = "fr"
+	LanguageGerman     Language = "de"
+	LanguageGreek      Language = "el"
+	LanguageHebrew     Language = "he"
+	LanguageHungarian  Language = "hu"
+	LanguageIndonesian Language = "id"
+	LanguageItalian    Language = "it"
+	LanguageJapanese   Language = "ja"
+	LanguageKorean     Language = "ko"
+	LanguageMalay      Language = "ms"
+	LanguageNorwegian  Language = "no"
+	LanguagePolish     Language = "pl"
+	LanguagePortuguese Language = "pt"
+	LanguageRomanian   Language = "ro"
+	LanguageRussian    Language = "ru"
+	LanguageSlovak     Language = "sk"
+	LanguageSpanish    Language = "es"
+	LanguageSwedish    Language = "sv"
+	LanguageThai       Language = "th"
+	LanguageTurkish    Language = "tr"
+	LanguageVietnamese Language = "vi"
+)
+
+// Languages are all of the languages supported by the API
+var Languages = []Language{
+	LanguageArabic, LanguageChinese, LanguageCzech, LanguageDanish, LanguageDutch,
+	LanguageEnglish, LanguageFinnish, LanguageFrench, LanguageGerman, LanguageGreek,
+	LanguageHebrew, LanguageHungarian, LanguageIndonesian, LanguageItalian, LanguageJapanese,
+	LanguageKorean, LanguageMalay, LanguageNorwegian, LanguagePolish, LanguagePortuguese,
+	LanguageRomanian, LanguageRussian, LanguageSlovak, LanguageSpanish, LanguageSwedish,
+	LanguageThai, LanguageTurkish, LanguageVietnamese,
+}
+
+// Valid reports whether the region is supported by the API
+func (r Region) Valid() bool {
+	for _, region := range Regions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether the language is supported by the API
+func (l Language) Valid() bool {
+	for _, language := range Languages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// Locale is the region and language of the API results. The zero value
+// uses the API default (ie: US English).
+type Locale struct {
+	Region   Region   // the region of the foods and servings
+	Language Language // the language of the results, which requires a region
+}
+
+// ParseLocale parses a locale such as "fr-FR", "FR" or "" into its
+// language and region
+func ParseLocale(s string) (Locale, error) {
+	// split the language and region
+	locale := Locale{}
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '_' })
+	switch len(parts) {
+	case 0:
+	case 1:
+		locale.Region = Region(strings.ToUpper(parts[0]))
+	case 2:
+		locale.Language = Language(strings.ToLower(parts[0]))
+		locale.Region = Region(strings.ToUpper(parts[1]))
+	default:
+		return Locale{}, fmt.Errorf("Invalid locale '%s' given", s)
+	}
+	return locale, locale.Validate()
+}
+
+// Validate checks that the region and language are supported
+func (l Locale) Validate() error {
+	if l.Region != "" && !l.Region.Valid() {
+		return fmt.Errorf("Invalid region '%s' given", l.Region)
+	}
+	if l.Language != "" && !l.Language.Valid() {
+		return fmt.Errorf("Invalid language '%s' given", l.Language)
+	}
+	if l.Language != "" && l.Region == "" {
+		return fmt.Errorf("Invalid locale: language '%s' given without a region", l.Language)
+	}
+	return nil
+}
+
+// String returns the locale as "language-REGION" (ie: "fr-FR")
+func (l Locale) String() string {
+	if l.Language == "" {
+		return string(l.Region)
+	}
+	return string(l.Language) + "-" + string(l.Region)
+}
+
+// WithLocale sets the default locale of the client's API calls. NewClient
+// fails when the locale is not supported.
+func WithLocale(locale Locale) Option {
+	return func(c *Client) {
+		c.locale = locale
+	}
+}
+
+// ForLocale overrides the client's locale for a single call, when given
+// to WithCallOptions. The call fails, without sending a request, when the
+// locale is not supported.
+func ForLocale(locale Locale) CallOption {
+	return func(o *callOptions) {
+		o.locale = &locale
+	}
+}
+
+// localizedMethods are the localized API methods which the client
+// implements and whether they accept a language in addition to a region
+var localizedMethods = map[string]bool{
+	"foods.search":             true,
+	"foods.search.v3":          true,
+	"food.get":                 true,
+	"food.get.v2":              true,
+	"food.get.v4":              true,
+	"food.find_id_for_barcode": true,
+	"food_brands.get":          false,
+}
+
+// callLocale returns the locale of the call, or else of the client
+func (c *Client) callLocale(o callOptions) Locale {
+	if o.locale != nil {
+		return *o.locale
+	}
+	return c.locale
+}
+
+// localize adds the locale of the call, or else of the client, to the
+// parameters of a localized API method. Parameters given by the caller
+// are kept.
+func (c *Client) localize(apiMethod string, params map[string]string, o callOptions) (map[string]string, error) {
+	// determine the locale of the call
+	locale := c.callLocale(o)
+	if locale == (Locale{}) {
+		return params, nil
+	}
+	if err := locale.Validate(); err != nil {
+		return nil, err
+	}
+	withLanguage, ok := localizedMethods[apiMethod]
+	if !ok {
+		return params, nil
+	}
+
+	// copy the parameters, adding the locale
+	localized := make(map[string]string, len(params)+2)
+	for k, v := range params {
+		localized[k] = v
+	}
+	if _, ok := localized["region"]; !ok && locale.Region != "" {
+		localized["region"] = string(locale.Region)
+	}
+	if _, ok := localized["language"]; !ok && locale.Language != "" && withLanguage {
+		localized["language"] = string(locale.Language)
+	}
+	return localized, nil
+}
@@ -0,0 +1,93 @@
+package fatsecret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseLocale(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		locale string
+		want   Locale
+		valid  bool
+	}{
+		{"", Locale{}, true},
+		{"US", Locale{Region: RegionUnitedStates}, true},
+		{"gb", Locale{Region: RegionUnitedKingdom}, true},
+		{"fr-FR", Locale{Region: RegionFrance, Language: LanguageFrench}, true},
+		{"en_CA", Locale{Region: RegionCanada, Language: LanguageEnglish}, true},
+		{"XX", Locale{}, false},
+		{"xx-FR", Locale{}, false},
+		{"fr-FR-x", Locale{}, false},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		got, err := ParseLocale(tc.locale)
+		if valid := err == nil; valid != tc.valid {
+			t.Errorf("got error '%v' for '%s'; want valid %v", err, tc.locale, tc.valid)
+			continue
+		}
+		if tc.valid && got != tc.want {
+			t.Errorf("got '%+v' for '%s'; want '%+v'", got, tc.locale, tc.want)
+		}
+	}
+}
+
+func TestLocaleValidate(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		locale Locale
+		valid  bool
+	}{
+		{Locale{}, true},
+		{Locale{Region: RegionGermany}, true},
+		{Locale{Region: RegionGermany, Language: LanguageGerman}, true},
+		{Locale{Region: "us"}, false},
+		{Locale{Region: RegionGermany, Language: "xx"}, false},
+		{Locale{Language: LanguageGerman}, false},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		err := tc.locale.Validate()
+		if valid := err == nil; valid != tc.valid {
+			t.Errorf("got error '%v' for '%+v'; want valid %v", err, tc.locale, tc.valid)
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	c := &Client{locale: Locale{Region: RegionFrance, Language: LanguageFrench}}
+	german := Locale{Region: RegionGermany}
+
+	// define the test-cases
+	testCases := []struct {
+		method string
+		params map[string]string
+		opts   []CallOption
+		want   map[string]string
+	}{
+		{"food.get", map[string]string{"food_id": "1"}, nil, map[string]string{"food_id": "1", "region": "FR", "language": "fr"}},
+		{"food.get", map[string]string{"food_id": "1"}, []CallOption{ForLocale(german)}, map[string]string{"food_id": "1", "region": "DE"}},
+		{"food.get", map[string]string{"region": "US"}, nil, map[string]string{"region": "US", "language": "fr"}},
+		{"foods.search.v3", map[string]string{"search_expression": "soup"}, nil, map[string]string{"search_expression": "soup", "region": "FR", "language": "fr"}},
+		{"food_brands.get", map[string]string{"brand_type": "manufacturer"}, nil, map[string]string{"brand_type": "manufacturer", "region": "FR"}},
+		{"food_brands.get", map[string]string{"brand_type": "manufacturer", "region": "US"}, []CallOption{ForLocale(german)}, map[string]string{"brand_type": "manufacturer", "region": "US"}},
+		{"recipes.search.v3", map[string]string{"search_expression": "soup"}, nil, map[string]string{"search_expression": "soup"}},
+		{"food_categories.get", map[string]string{}, nil, map[string]string{}},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		got, err := c.localize(tc.method, tc.params, newCallOptions(context.Background(), tc.opts))
+		if err != nil {
+			t.Fatalf("Could not localize '%s': '%v'", tc.method, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("got '%v' for '%s'; want '%v'", got, tc.method, tc.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package match
+
+import (
+	"math"
+	"strings"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/nutrition"
+)
+
+// DefaultNutrientTolerance is the relative difference of the calories
+// and macro-nutrients of duplicate foods
+const DefaultNutrientTolerance = 0.1
+
+// GroupOptions configure how duplicate foods are grouped. The zero value
+// uses the default thresholds.
+type GroupOptions struct {
+	NameThreshold     float64 // the normalized name similarity of duplicates (DefaultDuplicateThreshold when zero)
+	NutrientTolerance float64 // the relative nutrient difference of duplicates (DefaultNutrientTolerance when zero)
+	SameBrand         bool    // only group foods of the same brand
+}
+
+// ItemGroup is a group of duplicate search results
+type ItemGroup struct {
+	Canonical fatsecret.FoodSearchItem   // the item which represents the group
+	Items     []fatsecret.FoodSearchItem // all of the items of the group, in their original order
+}
+
+// FoodGroup is a group of duplicate foods
+type FoodGroup struct {
+	Canonical fatsecret.FoodInfo   // the food which represents the group
+	Foods     []fatsecret.FoodInfo // all of the foods of the group, in their original order
+}
+
+// GroupItems groups the duplicate search results. Results are duplicates
+// when their names, without the brand name, are similar and, when both
+// descriptions can be parsed for the same serving basis, their calories
+// and macro-nutrients are within the tolerance. Groups are returned in
+// the order of their first result, so ranked results stay ranked.
+func GroupItems(items []fatsecret.FoodSearchItem, opts GroupOptions) []ItemGroup {
+	// describe each item
+	members := make([]member, len(items))
+	for i, item := range items {
+		members[i] = newMember(item.Name, item.BrandName, item.Type)
+		if n, err := item.ParseDescription(); err == nil {
+			members[i].macros = &macros{
+				basis:        strings.ToLower(strings.Join(strings.Fields(n.Basis), " ")),
+				calories:     n.Calories,
+				fat:          n.Fat,
+				carbohydrate: n.Carbohydrate,
+				protein:      n.Protein,
+			}
+		}
+	}
+
+	// build the groups of items
+	groups := []ItemGroup{}
+	for _, c := range cluster(members, opts) {
+		group := ItemGroup{Canonical: items[c.canonical]}
+		for _, i := range c.members {
+			group.Items = append(group.Items, items[i])
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// GroupFoods groups the duplicate foods. Foods are duplicates when their
+// names, without the brand name, are similar and, when both can be
+// measured in grams, their calories and macro-nutrients per 100 g are
+// within the tolerance. Groups are returned in the order of their first
+// food.
+func GroupFoods(foods []fatsecret.FoodInfo, opts GroupOptions) []FoodGroup {
+	// describe each food
+	members := make([]member, len(foods))
+	for i := range foods {
+		members[i] = newMember(foods[i].Name, foods[i].BrandName, foods[i].Type)
+		if p, err := nutrition.ScaleFood(&foods[i], nutrition.Quantity{Amount: 100, Unit: nutrition.UnitGram}); err == nil {
+			members[i].macros = &macros{
+				basis:        "100 g",
+				calories:     p[nutrition.Calories],
+				fat:          p[nutrition.Fat],
+				carbohydrate: p[nutrition.Carbohydrate],
+				protein:      p[nutrition.Protein],
+			}
+		}
+	}
+
+	// build the groups of foods
+	groups := []FoodGroup{}
+	for _, c := range cluster(members, opts) {
+		group := FoodGroup{Canonical: foods[c.canonical]}
+		for _, i := range c.members {
+			group.Foods = append(group.Foods, foods[i])
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// member describes a search result or a food for grouping
+type member struct {
+	name    string  // the normalized name, without the brand name
+	brand   string  // the normalized brand name
+	generic bool    // whether the food is generic
+	macros  *macros // the nutrients, or nil when unknown
+}
+
+// macros are the calories and macro-nutrients of a serving basis
+type macros struct {
+	basis                                string
+	calories, fat, carbohydrate, protein float64
+}
+
+// memberCluster is a group of member indices
+type memberCluster struct {
+	canonical int
+	members   []int
+}
+
+// newMember describes a food, dropping the brand name from its name
+// (ie: "Acme Peanut Butter" of the "Acme" brand becomes "peanut butter")
+func newMember(name string, brand string, t fatsecret.FoodType) member {
+	m := member{
+		name:    Normalize(name),
+		brand:   Normalize(brand),
+		generic: t == fatsecret.FoodTypeGeneric,
+	}
+	if m.brand != "" && m.name != m.brand {
+		m.name = strings.TrimSpace(strings.TrimPrefix(m.name, m.brand+" "))
+	}
+	return m
+}
+
+// cluster groups each member with the first group whose first member
+// it duplicates. The canonical member of each group is its first
+// generic member, or else its first member.
+func cluster(members []member, opts GroupOptions) []memberCluster {
+	// default the thresholds
+	if opts.NameThreshold <= 0 {
+		opts.NameThreshold = DefaultDuplicateThreshold
+	}
+	if opts.NutrientTolerance <= 0 {
+		opts.NutrientTolerance = DefaultNutrientTolerance
+	}
+
+	clusters := []memberCluster{}
+	for i, m := range members {
+		// add the member to the first group it duplicates
+		found := false
+		for c := range clusters {
+			if duplicates(members[clusters[c].members[0]], m, opts) {
+				clusters[c].members = append(clusters[c].members, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			clusters = append(clusters, memberCluster{canonical: i, members: []int{i}})
+		}
+	}
+
+	// prefer a generic food as the canonical member
+	for c := range clusters {
+		for _, i := range clusters[c].members {
+			if members[i].generic {
+				clusters[c].canonical = i
+				break
+			}
+		}
+	}
+	return clusters
+}
+
+// duplicates reports whether the members are duplicates of each other
+func duplicates(a member, b member, opts GroupOptions) bool {
+	// if the brands or the names differ
+	if opts.SameBrand && a.brand != b.brand {
+		return false
+	}
+	if Similarity(a.name, b.name) < opts.NameThreshold {
+		return false
+	}
+
+	// nutrients can only be compared for the same serving basis
+	if a.macros == nil || b.macros == nil || a.macros.basis != b.macros.basis {
+		return true
+	}
+	return similar(a.macros.calories, b.macros.calories, opts.NutrientTolerance) &&
+		similar(a.macros.fat, b.macros.fat, opts.NutrientTolerance) &&
+		similar(a.macros.carbohydrate, b.macros.carbohydrate, opts.NutrientTolerance) &&
+		similar(a.macros.protein, b.macros.protein, opts.NutrientTolerance)
+}
+
+// similar reports whether the amounts differ by at most the tolerance,
+// relative to the larger amount. Amounts below one are compared as one,
+// so trace amounts (ie: 0.02 g and 0.08 g of fat) are similar.
+func similar(a float64, b float64, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance*math.Max(1, math.Max(a, b))
+}
@@ -0,0 +1,105 @@
+package match_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/match"
+)
+
+// groupIDs returns the canonical id and the member ids of each group
+func groupIDs(groups []match.ItemGroup) [][]string {
+	got := [][]string{}
+	for _, g := range groups {
+		ids := []string{g.Canonical.ID}
+		for _, item := range g.Items {
+			ids = append(ids, item.ID)
+		}
+		got = append(got, ids)
+	}
+	return got
+}
+
+func TestGroupItems(t *testing.T) {
+	items := []fatsecret.FoodSearchItem{
+		{ID: "1", Name: "Acme Peanut Butter", BrandName: "Acme", Type: fatsecret.FoodTypeBrand,
+			Description: "Per 2 tbsp - Calories: 190kcal | Fat: 16.00g | Carbs: 7.00g | Protein: 8.00g"},
+		{ID: "2", Name: "Peanut Butter", Type: fatsecret.FoodTypeGeneric,
+			Description: "Per 2 tbsp - Calories: 188kcal | Fat: 16.10g | Carbs: 6.90g | Protein: 7.70g"},
+		{ID: "3", Name: "Peanut Butter", BrandName: "Bolt", Type: fatsecret.FoodTypeBrand,
+			Description: "Per 2 tbsp - Calories: 140kcal | Fat: 8.00g | Carbs: 12.00g | Protein: 6.00g"},
+		{ID: "4", Name: "Peanut Butters", BrandName: "Crest", Type: fatsecret.FoodTypeBrand,
+			Description: "Per 100g - Calories: 588kcal | Fat: 50.00g | Carbs: 20.00g | Protein: 25.00g"},
+		{ID: "5", Name: "Almond Butter", Type: fatsecret.FoodTypeGeneric},
+	}
+
+	// define the test-cases
+	testCases := []struct {
+		name string
+		opts match.GroupOptions
+		want [][]string
+	}{
+		{
+			"default",
+			match.GroupOptions{},
+			[][]string{{"2", "1", "2", "4"}, {"3", "3"}, {"5", "5"}},
+		},
+		{
+			"same brand",
+			match.GroupOptions{SameBrand: true},
+			[][]string{{"1", "1"}, {"2", "2"}, {"3", "3"}, {"4", "4"}, {"5", "5"}},
+		},
+		{
+			"loose nutrients",
+			match.GroupOptions{NutrientTolerance: 0.5},
+			[][]string{{"2", "1", "2", "3", "4"}, {"5", "5"}},
+		},
+		{
+			"loose names",
+			match.GroupOptions{NameThreshold: 0.5, NutrientTolerance: 0.5},
+			[][]string{{"2", "1", "2", "3", "4", "5"}},
+		},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			if got := groupIDs(match.GroupItems(items, tc.opts)); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got '%v'; want '%v'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGroupFoods(t *testing.T) {
+	serving := func(calories string) fatsecret.FoodServings {
+		return fatsecret.FoodServings{Serving: []fatsecret.FoodServing{{
+			MeasurementDescription: "g", NumberOfUnits: "100", MetricServingAmount: "100", MetricServingUnit: "g",
+			Calories: calories, Fat: "1", Carbohydrate: "20", Protein: "3",
+		}}}
+	}
+	foods := []fatsecret.FoodInfo{
+		{ID: "1", Name: "Greek Yogurt", BrandName: "Acme", Type: fatsecret.FoodTypeBrand, Servings: serving("100")},
+		{ID: "2", Name: "Greek Yogurt", BrandName: "Bolt", Type: fatsecret.FoodTypeBrand, Servings: serving("104")},
+		{ID: "3", Name: "Greek Yogurt", BrandName: "Crest", Type: fatsecret.FoodTypeBrand, Servings: serving("150")},
+		{ID: "4", Name: "Greek Yoghurt", Type: fatsecret.FoodTypeGeneric},
+	}
+
+	groups := match.GroupFoods(foods, match.GroupOptions{})
+	got := [][]string{}
+	for _, g := range groups {
+		ids := []string{g.Canonical.ID}
+		for _, food := range g.Foods {
+			ids = append(ids, food.ID)
+		}
+		got = append(got, ids)
+	}
+
+	// the foods without nutrients are grouped by name alone
+	want := [][]string{{"4", "1", "2", "4"}, {"3", "3"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got '%v'; want '%v'", got, want)
+	}
+}
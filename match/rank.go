@@ -0,0 +1,271 @@
+/*
+Package match re-ranks FatSecret food search results against the query,
+so that the obvious generic match comes before obscure branded items.
+
+	ranker := match.NewRanker(match.WithPreferredBrands(0.2, "Acme"))
+	items, err := client.FoodSearch("chicken breast")
+	for _, r := range ranker.Rank("chicken breast", items) {
+		fmt.Println(r.Score, r.Item.Name)
+	}
+*/
+package match
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/fitzone/fatsecret"
+)
+
+// the default ranking adjustments
+const (
+	DefaultGenericBoost       = 0.1 // added to the score of generic foods
+	DefaultDuplicatePenalty   = 0.1 // subtracted from the score of near-duplicates
+	DefaultDuplicateThreshold = 0.9 // the name similarity of near-duplicates
+)
+
+// TokenThreshold is the edit distance similarity at which two words are
+// considered the same misspelled word (ie: "chiken" and "chicken")
+const TokenThreshold = 0.8
+
+// Scorer scores how well a search result matches the query, from 0 for
+// no match to 1 for a perfect match
+type Scorer interface {
+	Score(query string, item fatsecret.FoodSearchItem) float64
+}
+
+// ScorerFunc adapts a function into a Scorer
+type ScorerFunc func(query string, item fatsecret.FoodSearchItem) float64
+
+// Score calls the function
+func (f ScorerFunc) Score(query string, item fatsecret.FoodSearchItem) float64 {
+	return f(query, item)
+}
+
+// TokenOverlap scores the Dice coefficient of the distinct normalized
+// words of the query and of the item's name. Words with an edit distance
+// similarity of at least TokenThreshold are counted as the same word, and
+// each word of the name matches at most one query word.
+var TokenOverlap Scorer = ScorerFunc(func(query string, item fatsecret.FoodSearchItem) float64 {
+	want, got := distinct(Tokens(query)), distinct(Tokens(item.Name))
+	if len(want) == 0 || len(got) == 0 {
+		return 0
+	}
+
+	// count the query words found in the name
+	common := 0
+	used := make([]bool, len(got))
+	for _, w := range want {
+		for i, g := range got {
+			if !used[i] && (w == g || Similarity(w, g) >= TokenThreshold) {
+				used[i] = true
+				common++
+				break
+			}
+		}
+	}
+	return 2 * float64(common) / float64(len(want)+len(got))
+})
+
+// distinct returns the words without their repeats, in their original order
+func distinct(words []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			unique = append(unique, w)
+		}
+	}
+	return unique
+}
+
+// EditSimilarity scores the average edit distance similarity of each
+// query word to its closest word of the item's name, so that misspelled
+// queries (ie: "chiken") still match
+var EditSimilarity Scorer = ScorerFunc(func(query string, item fatsecret.FoodSearchItem) float64 {
+	want, got := Tokens(query), Tokens(item.Name)
+	if len(want) == 0 || len(got) == 0 {
+		return 0
+	}
+
+	// sum the similarity of each query word to its closest name word
+	total := 0.0
+	for _, w := range want {
+		best := 0.0
+		for _, g := range got {
+			best = max(best, Similarity(w, g))
+		}
+		total += best
+	}
+
+	// names with extra words are a weaker match
+	return total / float64(max(len(want), len(got)))
+})
+
+// weightedScorer is a scorer with its weight in the combined score
+type weightedScorer struct {
+	scorer Scorer
+	weight float64
+}
+
+// Ranker re-ranks food search results. The score of each result is the
+// weighted average of its scorers, plus the generic and brand boosts,
+// minus the penalty of near-duplicates of better ranked results.
+type Ranker struct {
+	scorers            []weightedScorer
+	genericBoost       float64
+	brands             map[string]float64
+	duplicatePenalty   float64
+	duplicateThreshold float64
+}
+
+// Option is a functional option which configures a Ranker
+type Option func(*Ranker)
+
+// WithScorer adds a scorer with the given weight. When no scorer is
+// added, TokenOverlap and EditSimilarity are used with equal weights.
+func WithScorer(weight float64, scorer Scorer) Option {
+	return func(r *Ranker) {
+		r.scorers = append(r.scorers, weightedScorer{scorer: scorer, weight: weight})
+	}
+}
+
+// WithGenericBoost sets the boost added to the score of generic foods.
+// Zero disables the boost.
+func WithGenericBoost(boost float64) Option {
+	return func(r *Ranker) {
+		r.genericBoost = boost
+	}
+}
+
+// WithPreferredBrands adds a boost to the score of foods of the given
+// brands, which are not case sensitive
+func WithPreferredBrands(boost float64, brands ...string) Option {
+	return func(r *Ranker) {
+		for _, brand := range brands {
+			r.brands[Normalize(brand)] = boost
+		}
+	}
+}
+
+// WithDuplicatePenalty sets the penalty subtracted from the score of a
+// result whose normalized name has at least the given similarity to a
+// better ranked result. Zero disables the penalty.
+func WithDuplicatePenalty(penalty float64, threshold float64) Option {
+	return func(r *Ranker) {
+		r.duplicatePenalty = penalty
+		r.duplicateThreshold = threshold
+	}
+}
+
+// NewRanker creates a ranker with the given options
+func NewRanker(opts ...Option) *Ranker {
+	r := &Ranker{
+		genericBoost:       DefaultGenericBoost,
+		brands:             map[string]float64{},
+		duplicatePenalty:   DefaultDuplicatePenalty,
+		duplicateThreshold: DefaultDuplicateThreshold,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	// default to the built-in scorers
+	if len(r.scorers) == 0 {
+		r.scorers = []weightedScorer{{TokenOverlap, 1}, {EditSimilarity, 1}}
+	}
+	return r
+}
+
+// Ranked is a search result with its ranking score
+type Ranked struct {
+	Item      fatsecret.FoodSearchItem
+	Score     float64 // the ranking score, higher is better
+	Position  int     // the zero-based position in the API results
+	Duplicate bool    // whether the item is a near-duplicate of a better ranked item
+}
+
+// Rank scores the search results against the query and returns them
+// best first. Equal scores keep the API order.
+func (r *Ranker) Rank(query string, items []fatsecret.FoodSearchItem) []Ranked {
+	// score each item
+	ranked := make([]Ranked, len(items))
+	for i, item := range items {
+		ranked[i] = Ranked{Item: item, Score: r.score(query, item), Position: i}
+	}
+	sortRanked(ranked)
+
+	// penalize the near-duplicates of better ranked items
+	if r.duplicatePenalty == 0 {
+		return ranked
+	}
+	names := make([]string, 0, len(ranked))
+	for i := range ranked {
+		name := Normalize(ranked[i].Item.Name)
+		for _, better := range names {
+			if Similarity(name, better) >= r.duplicateThreshold {
+				ranked[i].Score -= r.duplicatePenalty
+				ranked[i].Duplicate = true
+				break
+			}
+		}
+		if !ranked[i].Duplicate {
+			names = append(names, name)
+		}
+	}
+	sortRanked(ranked)
+	return ranked
+}
+
+// Sort returns the search results in ranked order
+func (r *Ranker) Sort(query string, items []fatsecret.FoodSearchItem) []fatsecret.FoodSearchItem {
+	sorted := make([]fatsecret.FoodSearchItem, len(items))
+	for i, ranked := range r.Rank(query, items) {
+		sorted[i] = ranked.Item
+	}
+	return sorted
+}
+
+// Search invokes the food search and ranks its results
+func (r *Ranker) Search(ctx context.Context, foods fatsecret.FoodService, query string, opts fatsecret.FoodSearchOptions) ([]Ranked, error) {
+	items, err := foods.FoodSearchWithOptions(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.Rank(query, items), nil
+}
+
+// score returns the ranking score of the item, before any duplicate penalty
+func (r *Ranker) score(query string, item fatsecret.FoodSearchItem) float64 {
+	// average the weighted scorers
+	total, weights := 0.0, 0.0
+	for _, ws := range r.scorers {
+		total += ws.weight * ws.scorer.Score(query, item)
+		weights += ws.weight
+	}
+	score := 0.0
+	if weights > 0 {
+		score = total / weights
+	}
+
+	// boost the generic foods and the preferred brands
+	if item.Type == fatsecret.FoodTypeGeneric {
+		score += r.genericBoost
+	}
+	if brand := strings.TrimSpace(item.BrandName); brand != "" {
+		score += r.brands[Normalize(brand)]
+	}
+	return score
+}
+
+// sortRanked sorts by descending score, keeping the API order of ties
+func sortRanked(ranked []Ranked) {
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Position < ranked[j].Position
+	})
+}
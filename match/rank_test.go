@@ -0,0 +1,140 @@
+package match_test
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/fatsecrettest"
+	"github.com/fitzone/fatsecret/match"
+)
+
+// chickenItems are search results in a typical API order
+var chickenItems = []fatsecret.FoodSearchItem{
+	{ID: "1", Name: "Chicken Breast Strips", Type: fatsecret.FoodTypeBrand, BrandName: "Acme"},
+	{ID: "2", Name: "Grilled Chicken Breast Sandwich", Type: fatsecret.FoodTypeBrand, BrandName: "Burger Barn"},
+	{ID: "3", Name: "Chicken Breast", Type: fatsecret.FoodTypeGeneric},
+	{ID: "4", Name: "Chicken Breasts", Type: fatsecret.FoodTypeBrand, BrandName: "Farmway"},
+	{ID: "5", Name: "Beef Steak", Type: fatsecret.FoodTypeGeneric},
+}
+
+// ids returns the ids of the ranked items
+func ids(ranked []match.Ranked) []string {
+	got := []string{}
+	for _, r := range ranked {
+		got = append(got, r.Item.ID)
+	}
+	return got
+}
+
+func TestRank(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		name  string
+		query string
+		opts  []match.Option
+		want  []string
+	}{
+		{
+			"generic match first",
+			"chicken breast",
+			nil,
+			[]string{"3", "4", "1", "2", "5"},
+		},
+		{
+			"misspelled query",
+			"chiken brest",
+			nil,
+			[]string{"3", "4", "1", "2", "5"},
+		},
+		{
+			"no generic boost or duplicate penalty",
+			"chicken breast",
+			[]match.Option{match.WithGenericBoost(0), match.WithDuplicatePenalty(0, 0)},
+			[]string{"3", "4", "1", "2", "5"},
+		},
+		{
+			"preferred brand",
+			"chicken breast",
+			[]match.Option{match.WithPreferredBrands(0.5, "farmway")},
+			[]string{"4", "3", "1", "2", "5"},
+		},
+		{
+			"custom scorer",
+			"chicken breast",
+			[]match.Option{match.WithScorer(1, match.ScorerFunc(func(query string, item fatsecret.FoodSearchItem) float64 {
+				if item.BrandName == "Burger Barn" {
+					return 1
+				}
+				return 0
+			})), match.WithGenericBoost(0)},
+			[]string{"2", "1", "3", "5", "4"},
+		},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			ranked := match.NewRanker(tc.opts...).Rank(tc.query, chickenItems)
+			if got := ids(ranked); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got '%v'; want '%v'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTokenOverlapRange(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		query string
+		name  string
+		want  float64
+	}{
+		{"milk milk", "Milk", 1},
+		{"milk", "Milk Milk", 1},
+		{"chicken chicken", "Chicken Breast", 2.0 / 3},
+		{"milk", "Milky Milk", 2.0 / 3},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.query+" "+tc.name, func(t *testing.T) {
+			got := match.TokenOverlap.Score(tc.query, fatsecret.FoodSearchItem{Name: tc.name})
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("got '%v'; want '%v'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRankDuplicates(t *testing.T) {
+	ranked := match.NewRanker().Rank("chicken breast", chickenItems)
+
+	// only the plural chicken breasts duplicate a better ranked item
+	for _, r := range ranked {
+		if want := r.Item.ID == "4"; r.Duplicate != want {
+			t.Errorf("got duplicate %v for '%s'; want %v", r.Duplicate, r.Item.Name, want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	fake := fatsecrettest.NewFake()
+	fake.AddSearchResults("chicken breast", chickenItems...)
+
+	ranked, err := match.NewRanker().Search(context.Background(), fake, "chicken breast", fatsecret.FoodSearchOptions{})
+	if err != nil {
+		t.Fatalf("Could not search foods: '%v'", err)
+	}
+	if len(ranked) != len(chickenItems) || ranked[0].Item.ID != "3" || ranked[0].Position != 2 {
+		t.Errorf("got '%+v'; want the generic chicken breast first", ranked)
+	}
+	sorted := match.NewRanker().Sort("chicken breast", chickenItems)
+	if sorted[0].ID != "3" || sorted[len(sorted)-1].ID != "5" {
+		t.Errorf("got '%+v'; want the generic chicken breast first and the beef last", sorted)
+	}
+}
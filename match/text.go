@@ -0,0 +1,61 @@
+package match
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalize lower-cases the food name, drops its punctuation and makes
+// its words singular (ie: "Eggs, Scrambled" becomes "egg scrambled")
+func Normalize(name string) string {
+	return strings.Join(Tokens(name), " ")
+}
+
+// Tokens returns the normalized words of the food name
+func Tokens(name string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '%'
+	})
+	for i, w := range fields {
+		// make the word singular
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			fields[i] = w[:len(w)-1]
+		}
+	}
+	return fields
+}
+
+// Distance returns the Levenshtein edit distance between the strings,
+// counting the inserted, deleted and substituted characters
+func Distance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// compute the distances one row at a time
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Similarity returns the edit distance similarity of the strings, from
+// 0 for entirely different strings to 1 for equal strings
+func Similarity(a string, b string) float64 {
+	longest := max(len([]rune(a)), len([]rune(b)))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(Distance(a, b))/float64(longest)
+}
@@ -0,0 +1,54 @@
+package match
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		name string
+		want string
+	}{
+		{"Eggs, Scrambled", "egg scrambled"},
+		{"  Chicken   Breast (Skinless) ", "chicken breast skinless"},
+		{"2% Milk", "2% milk"},
+		{"Hummus", "hummu"},
+		{"Swiss Cheese", "swiss cheese"},
+		{"", ""},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		if got := Normalize(tc.name); got != tc.want {
+			t.Errorf("got '%s' for '%s'; want '%s'", got, tc.name, tc.want)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		a, b       string
+		distance   int
+		similarity float64
+	}{
+		{"chicken", "chicken", 0, 1},
+		{"chiken", "chicken", 1, 6.0 / 7},
+		{"kitten", "sitting", 3, 4.0 / 7},
+		{"", "egg", 3, 0},
+		{"", "", 0, 1},
+		{"crème", "creme", 1, 0.8},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		if got := Distance(tc.a, tc.b); got != tc.distance {
+			t.Errorf("got distance %d for '%s' and '%s'; want %d", got, tc.a, tc.b, tc.distance)
+		}
+		if got := Similarity(tc.a, tc.b); math.Abs(got-tc.similarity) > 1e-9 {
+			t.Errorf("got similarity %v for '%s' and '%s'; want %v", got, tc.a, tc.b, tc.similarity)
+		}
+	}
+}
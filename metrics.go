@@ -0,0 +1,27 @@
+package fatsecret
+
+import (
+	"time"
+)
+
+// Metrics receives measurements of the client's API calls, such as to
+// feed a dashboard. Implementations must be safe for concurrent use.
+// The metrics package provides an in-memory implementation with a
+// Prometheus text exposition.
+type Metrics interface {
+	// ObserveResponse is called after every http attempt of an API call
+	ObserveResponse(info ResponseInfo)
+	// ObserveRetry is called before an API call is retried
+	ObserveRetry(method string, attempt int)
+	// ObserveRateLimitWait is called with the time an API call waited for the rate limiter
+	ObserveRateLimitWait(method string, wait time.Duration)
+	// ObserveCache is called when an API call is looked up in the response cache
+	ObserveCache(method string, hit bool)
+}
+
+// WithMetrics sets the metrics which measure the client's API calls
+func WithMetrics(metrics Metrics) Option {
+	return func(c *Client) {
+		c.metrics = metrics
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Package metrics provides an in-memory implementation of the fatsecret
+Metrics interface, without external dependencies, along with a
+Prometheus-style text exposition of the collected metrics.
+
+	collector := metrics.NewCollector()
+	client, err := fatsecret.NewClient(key, secret, fatsecret.WithMetrics(collector))
+	http.Handle("/metrics", collector)
+*/
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fitzone/fatsecret"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the latency histogram
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// error labels used for failures without a FatSecret error code
+const (
+	errorTransport = "transport"
+	errorHTTP      = "http_"
+)
+
+// Histogram is a cumulative latency histogram
+type Histogram struct {
+	Buckets []float64 // the upper bounds of the buckets, in seconds
+	Counts  []uint64  // the cumulative count of observations in each bucket
+	Count   uint64    // the total number of observations
+	Sum     float64   // the sum of the observations, in seconds
+}
+
+// MethodStats are the metrics of a single API method
+type MethodStats struct {
+	Requests      uint64            // the number of http attempts
+	Latency       Histogram         // the latency of the http attempts
+	Errors        map[string]uint64 // the failed attempts by FatSecret error code, "transport" or "http_<status>"
+	Retries       uint64            // the number of retries
+	RateLimitWait time.Duration     // the total time spent waiting for the rate limiter
+	CacheHits     uint64            // the number of cache hits
+	CacheMisses   uint64            // the number of cache misses
+}
+
+// CacheHitRatio returns the ratio of cache lookups which were hits
+func (s MethodStats) CacheHitRatio() float64 {
+	total := s.CacheHits + s.CacheMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.CacheHits) / float64(total)
+}
+
+// Collector collects the metrics of a fatsecret client in memory
+type Collector struct {
+	buckets []float64
+
+	mu      sync.Mutex
+	methods map[string]*MethodStats
+}
+
+// verify the collector implements the client metrics
+var _ fatsecret.Metrics = (*Collector)(nil)
+
+// NewCollector creates a collector using the DefaultBuckets
+func NewCollector() *Collector {
+	return NewCollectorWithBuckets(DefaultBuckets)
+}
+
+// NewCollectorWithBuckets creates a collector using the given latency
+// histogram bucket upper bounds, in seconds
+func NewCollectorWithBuckets(buckets []float64) *Collector {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &Collector{
+		buckets: sorted,
+		methods: map[string]*MethodStats{},
+	}
+}
+
+// ObserveResponse records the request count, latency and any error of an http attempt
+func (c *Collector) ObserveResponse(info fatsecret.ResponseInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats(info.Method)
+
+	// count the request and observe its latency
+	stats.Requests++
+	seconds := info.Latency.Seconds()
+	stats.Latency.Count++
+	stats.Latency.Sum += seconds
+	for i, bound := range stats.Latency.Buckets {
+		if seconds <= bound {
+			stats.Latency.Counts[i]++
+		}
+	}
+
+	// count any error by its code
+	switch {
+	case info.ErrorCode != 0:
+		stats.Errors[strconv.Itoa(info.ErrorCode)]++
+	case info.StatusCode >= 300:
+		stats.Errors[errorHTTP+strconv.Itoa(info.StatusCode)]++
+	case info.Err != nil:
+		stats.Errors[errorTransport]++
+	}
+}
+
+// ObserveRetry counts a retry of the API method
+func (c *Collector) ObserveRetry(method string, attempt int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats(method).Retries++
+}
+
+// ObserveRateLimitWait adds the rate limiter wait time of the API method
+func (c *Collector) ObserveRateLimitWait(method string, wait time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats(method).RateLimitWait += wait
+}
+
+// ObserveCache counts a cache hit or miss of the API method
+func (c *Collector) ObserveCache(method string, hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit {
+		c.stats(method).CacheHits++
+	} else {
+		c.stats(method).CacheMisses++
+	}
+}
+
+// Snapshot returns a copy of the metrics of each API method
+func (c *Collector) Snapshot() map[string]MethodStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot := make(map[string]MethodStats, len(c.methods))
+	for method, stats := range c.methods {
+		s := *stats
+		s.Latency.Buckets = append([]float64(nil), stats.Latency.Buckets...)
+		s.Latency.Counts = append([]uint64(nil), stats.Latency.Counts...)
+		s.Errors = make(map[string]uint64, len(stats.Errors))
+		for code, count := range stats.Errors {
+			s.Errors[code] = count
+		}
+		snapshot[method] = s
+	}
+	return snapshot
+}
+
+// stats returns the metrics of the method, creating them when needed.
+// The lock must be held.
+func (c *Collector) stats(method string) *MethodStats {
+	stats, ok := c.methods[method]
+	if !ok {
+		stats = &MethodStats{
+			Latency: Histogram{
+				Buckets: c.buckets,
+				Counts:  make([]uint64, len(c.buckets)),
+			},
+			Errors: map[string]uint64{},
+		}
+		c.methods[method] = stats
+	}
+	return stats
+}
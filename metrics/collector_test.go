@@ -0,0 +1,141 @@
+package metrics_test
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/fatsecrettest"
+	"github.com/fitzone/fatsecret/metrics"
+)
+
+func TestCollector(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+
+	// the first food.get attempt fails with a 503 and is retried
+	srv.InjectFault("food.get", fatsecrettest.Fault{Status: http.StatusServiceUnavailable, Count: 1})
+	collector := metrics.NewCollector()
+	client, err := srv.NewClient(
+		fatsecret.WithMetrics(collector),
+		fatsecret.WithRetry(2, time.Millisecond),
+		fatsecret.WithRateLimit(time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if _, err := client.FoodByID("1"); err != nil {
+		t.Fatalf("Could not fetch food: '%v'", err)
+	}
+	if _, err := client.FoodByID("2"); err == nil {
+		t.Fatalf("got no error; want an invalid id error")
+	}
+	collector.ObserveCache("food.get", true)
+	collector.ObserveCache("food.get", false)
+
+	// verify the food.get metrics
+	stats, ok := collector.Snapshot()["food.get"]
+	if !ok {
+		t.Fatalf("got no food.get metrics")
+	}
+	if stats.Requests != 3 {
+		t.Errorf("got %d requests; want 3", stats.Requests)
+	}
+	if stats.Latency.Count != 3 {
+		t.Errorf("got %d latency observations; want 3", stats.Latency.Count)
+	}
+	if stats.Retries != 1 {
+		t.Errorf("got %d retries; want 1", stats.Retries)
+	}
+	if got := stats.Errors["http_503"]; got != 1 {
+		t.Errorf("got %d http_503 errors; want 1", got)
+	}
+	if got := stats.Errors["106"]; got != 1 {
+		t.Errorf("got %d '106' errors; want 1", got)
+	}
+	if got := stats.CacheHitRatio(); got != 0.5 {
+		t.Errorf("got cache hit ratio '%v'; want '0.5'", got)
+	}
+}
+
+func TestCollectorHistogram(t *testing.T) {
+	collector := metrics.NewCollectorWithBuckets([]float64{1, 0.1})
+	for _, latency := range []time.Duration{50 * time.Millisecond, 500 * time.Millisecond, 2 * time.Second} {
+		collector.ObserveResponse(fatsecret.ResponseInfo{Method: "food.get", Latency: latency})
+	}
+
+	// the buckets are sorted and cumulative
+	h := collector.Snapshot()["food.get"].Latency
+	tests := []struct {
+		bound float64
+		count uint64
+	}{
+		{0.1, 1},
+		{1, 2},
+	}
+	for i, tt := range tests {
+		if h.Buckets[i] != tt.bound || h.Counts[i] != tt.count {
+			t.Errorf("got bucket %v with %d; want %v with %d", h.Buckets[i], h.Counts[i], tt.bound, tt.count)
+		}
+	}
+	if h.Count != 3 || math.Abs(h.Sum-2.55) > 1e-9 {
+		t.Errorf("got count %d and sum '%v'; want 3 and '2.55'", h.Count, h.Sum)
+	}
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	collector := metrics.NewCollectorWithBuckets([]float64{0.1})
+	collector.ObserveResponse(fatsecret.ResponseInfo{Method: "food.get", Latency: 20 * time.Millisecond, StatusCode: 200})
+	collector.ObserveResponse(fatsecret.ResponseInfo{Method: "food.get", Latency: 20 * time.Millisecond, ErrorCode: 106})
+	collector.ObserveRetry("food.get", 1)
+	collector.ObserveRateLimitWait("food.get", 1500*time.Millisecond)
+	collector.ObserveCache("food.get", true)
+
+	// serve the exposition
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("got content type '%s'; want text/plain", got)
+	}
+
+	// verify the samples
+	body := rec.Body.String()
+	samples := []string{
+		`fatsecret_requests_total{method="food.get"} 2`,
+		`fatsecret_request_duration_seconds_bucket{method="food.get",le="0.1"} 2`,
+		`fatsecret_request_duration_seconds_bucket{method="food.get",le="+Inf"} 2`,
+		`fatsecret_request_duration_seconds_count{method="food.get"} 2`,
+		`fatsecret_errors_total{method="food.get",code="106"} 1`,
+		`fatsecret_retries_total{method="food.get"} 1`,
+		`fatsecret_rate_limit_wait_seconds_total{method="food.get"} 1.5`,
+		`fatsecret_cache_lookups_total{method="food.get",result="hit"} 1`,
+		`fatsecret_cache_lookups_total{method="food.get",result="miss"} 0`,
+		`# TYPE fatsecret_request_duration_seconds histogram`,
+	}
+	for _, sample := range samples {
+		if !strings.Contains(body, sample+"\n") {
+			t.Errorf("got exposition without '%s':\n%s", sample, body)
+		}
+	}
+}
+
+func TestPrometheusLabelEscaping(t *testing.T) {
+	collector := metrics.NewCollector()
+	collector.ObserveRetry("food\"get\\v2\n\t", 1)
+
+	// the label value is escaped like the Prometheus text format, which
+	// unlike Go quoting keeps other characters (ie: tabs) as they are
+	buf := &strings.Builder{}
+	if err := collector.WritePrometheus(buf); err != nil {
+		t.Fatalf("Could not write metrics: '%v'", err)
+	}
+	want := "fatsecret_retries_total{method=\"food\\\"get\\\\v2\\n\t\"} 1"
+	if !strings.Contains(buf.String(), want+"\n") {
+		t.Errorf("got exposition without '%s':\n%s", want, buf)
+	}
+}
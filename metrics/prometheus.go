@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the content type of the Prometheus text exposition format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// labelEscaper escapes a label value for the Prometheus text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the collected metrics in the Prometheus text
+// exposition format
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	snapshot := c.Snapshot()
+
+	// write the methods in a stable order
+	methods := make([]string, 0, len(snapshot))
+	for method := range snapshot {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	bw := bufio.NewWriter(w)
+
+	// request counts
+	fmt.Fprintln(bw, "# HELP fatsecret_requests_total FatSecret API http attempts by API method.")
+	fmt.Fprintln(bw, "# TYPE fatsecret_requests_total counter")
+	for _, m := range methods {
+		fmt.Fprintf(bw, "fatsecret_requests_total{method=%s} %d\n", labelValue(m), snapshot[m].Requests)
+	}
+
+	// latency histograms
+	fmt.Fprintln(bw, "# HELP fatsecret_request_duration_seconds FatSecret API http attempt latency by API method.")
+	fmt.Fprintln(bw, "# TYPE fatsecret_request_duration_seconds histogram")
+	for _, m := range methods {
+		h := snapshot[m].Latency
+		for i, bound := range h.Buckets {
+			fmt.Fprintf(bw, "fatsecret_request_duration_seconds_bucket{method=%s,le=%s} %d\n", labelValue(m), labelValue(formatFloat(bound)), h.Counts[i])
+		}
+		fmt.Fprintf(bw, "fatsecret_request_duration_seconds_bucket{method=%s,le=\"+Inf\"} %d\n", labelValue(m), h.Count)
+		fmt.Fprintf(bw, "fatsecret_request_duration_seconds_sum{method=%s} %s\n", labelValue(m), formatFloat(h.Sum))
+		fmt.Fprintf(bw, "fatsecret_request_duration_seconds_count{method=%s} %d\n", labelValue(m), h.Count)
+	}
+
+	// error counts by code
+	fmt.Fprintln(bw, "# HELP fatsecret_errors_total FatSecret API errors by API method and error code.")
+	fmt.Fprintln(bw, "# TYPE fatsecret_errors_total counter")
+	for _, m := range methods {
+		codes := make([]string, 0, len(snapshot[m].Errors))
+		for code := range snapshot[m].Errors {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Fprintf(bw, "fatsecret_errors_total{method=%s,code=%s} %d\n", labelValue(m), labelValue(code), snapshot[m].Errors[code])
+		}
+	}
+
+	// retry counts
+	fmt.Fprintln(bw, "# HELP fatsecret_retries_total FatSecret API retries by API method.")
+	fmt.Fprintln(bw, "# TYPE fatsecret_retries_total counter")
+	for _, m := range methods {
+		fmt.Fprintf(bw, "fatsecret_retries_total{method=%s} %d\n", labelValue(m), snapshot[m].Retries)
+	}
+
+	// rate limiter wait time
+	fmt.Fprintln(bw, "# HELP fatsecret_rate_limit_wait_seconds_total Time spent waiting for the rate limiter by API method.")
+	fmt.Fprintln(bw, "# TYPE fatsecret_rate_limit_wait_seconds_total counter")
+	for _, m := range methods {
+		fmt.Fprintf(bw, "fatsecret_rate_limit_wait_seconds_total{method=%s} %s\n", labelValue(m), formatFloat(snapshot[m].RateLimitWait.Seconds()))
+	}
+
+	// cache lookups
+	fmt.Fprintln(bw, "# HELP fatsecret_cache_lookups_total Response cache lookups by API method and result.")
+	fmt.Fprintln(bw, "# TYPE fatsecret_cache_lookups_total counter")
+	for _, m := range methods {
+		fmt.Fprintf(bw, "fatsecret_cache_lookups_total{method=%s,result=\"hit\"} %d\n", labelValue(m), snapshot[m].CacheHits)
+		fmt.Fprintf(bw, "fatsecret_cache_lookups_total{method=%s,result=\"miss\"} %d\n", labelValue(m), snapshot[m].CacheMisses)
+	}
+
+	return bw.Flush()
+}
+
+// ServeHTTP serves the collected metrics in the Prometheus text
+// exposition format, so the collector can be mounted at '/metrics'
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	c.WritePrometheus(w)
+}
+
+// formatFloat formats the value like the Prometheus client libraries
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// labelValue quotes and escapes the label value like the Prometheus text
+// exposition format, which only escapes backslashes, double quotes and
+// newlines
+func labelValue(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
@@ -0,0 +1,202 @@
+/*
+Package nutrition provides nutrition math on top of the FatSecret
+food and serving data, such as scaling a serving's nutrients to an
+arbitrary quantity and unit.
+*/
+package nutrition
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fitzone/fatsecret"
+)
+
+// Nutrient is the enum type for the nutrients of a FatSecret serving
+type Nutrient int
+
+const (
+	// Calories is the energy content in kcal
+	Calories Nutrient = iota
+	// Carbohydrate is the total carbohydrate content in grams
+	Carbohydrate
+	// Protein is the protein content in grams
+	Protein
+	// Fat is the total fat content in grams
+	Fat
+	// SaturatedFat is the saturated fat content in grams
+	SaturatedFat
+	// PolyunsaturatedFat is the polyunsaturated fat content in grams
+	PolyunsaturatedFat
+	// MonounsaturatedFat is the monounsaturated fat content in grams
+	MonounsaturatedFat
+	// TransFat is the trans fat content in grams
+	TransFat
+	// Cholesterol is the cholesterol content in milligrams
+	Cholesterol
+	// Sodium is the sodium content in milligrams
+	Sodium
+	// Potassium is the potassium content in milligrams
+	Potassium
+	// Fiber is the fiber content in grams
+	Fiber
+	// Sugar is the sugar content in grams
+	Sugar
+	// VitaminA is the percentage of daily recommended Vitamin A
+	VitaminA
+	// VitaminC is the percentage of daily recommended Vitamin C
+	VitaminC
+	// Calcium is the percentage of daily recommended Calcium
+	Calcium
+	// Iron is the percentage of daily recommended Iron
+	Iron
+	// AddedSugars is the added sugars content in grams (FoodByIDV4 only)
+	AddedSugars
+	// VitaminD is the vitamin D content in micrograms (FoodByIDV4 only)
+	VitaminD
+	// VitaminAMcg is the vitamin A content in micrograms (FoodByIDV4 only)
+	VitaminAMcg
+	// VitaminCMg is the vitamin C content in milligrams (FoodByIDV4 only)
+	VitaminCMg
+	// CalciumMg is the calcium content in milligrams (FoodByIDV4 only)
+	CalciumMg
+	// IronMg is the iron content in milligrams (FoodByIDV4 only)
+	IronMg
+)
+
+// Nutrients lists every nutrient in the order of the API documentation
+var Nutrients = []Nutrient{
+	Calories, Carbohydrate, Protein, Fat, SaturatedFat, PolyunsaturatedFat,
+	MonounsaturatedFat, TransFat, Cholesterol, Sodium, Potassium, Fiber,
+	Sugar, VitaminA, VitaminC, Calcium, Iron, AddedSugars, VitaminD,
+	VitaminAMcg, VitaminCMg, CalciumMg, IronMg,
+}
+
+// nutrientNames are the API field names of each nutrient
+var nutrientNames = map[Nutrient]string{
+	Calories:           "calories",
+	Carbohydrate:       "carbohydrate",
+	Protein:            "protein",
+	Fat:                "fat",
+	SaturatedFat:       "saturated_fat",
+	PolyunsaturatedFat: "polyunsaturated_fat",
+	MonounsaturatedFat: "monounsaturated_fat",
+	TransFat:           "trans_fat",
+	Cholesterol:        "cholesterol",
+	Sodium:             "sodium",
+	Potassium:          "potassium",
+	Fiber:              "fiber",
+	Sugar:              "sugar",
+	VitaminA:           "vitamin_a",
+	VitaminC:           "vitamin_c",
+	Calcium:            "calcium",
+	Iron:               "iron",
+	AddedSugars:        "added_sugars",
+	VitaminD:           "vitamin_d",
+	VitaminAMcg:        "vitamin_a_mcg",
+	VitaminCMg:         "vitamin_c_mg",
+	CalciumMg:          "calcium_mg",
+	IronMg:             "iron_mg",
+}
+
+// String returns the API field name of the nutrient (ie: "saturated_fat")
+func (n Nutrient) String() string {
+	if name, ok := nutrientNames[n]; ok {
+		return name
+	}
+	return fmt.Sprintf("Nutrient(%d)", int(n))
+}
+
+// Profile maps each nutrient to its amount. Nutrients which are not
+// available for a food are missing from the map, rather than zero.
+type Profile map[Nutrient]float64
+
+// ProfileOf parses the decimal nutrient strings of the given serving
+// into a nutrient profile
+func ProfileOf(s fatsecret.FoodServing) (Profile, error) {
+	p := Profile{}
+	for _, n := range Nutrients {
+		// skip nutrients which are not available
+		value := strings.TrimSpace(servingValue(s, n))
+		if value == "" {
+			continue
+		}
+
+		// parse the decimal nutrient value
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s value '%s' for serving '%s'", n, value, s.ServingID)
+		}
+		p[n] = f
+	}
+	return p, nil
+}
+
+// Get returns the amount of the nutrient and whether it is available
+func (p Profile) Get(n Nutrient) (float64, bool) {
+	v, ok := p[n]
+	return v, ok
+}
+
+// Scale returns a new profile with every nutrient multiplied by factor
+func (p Profile) Scale(factor float64) Profile {
+	scaled := make(Profile, len(p))
+	for n, v := range p {
+		scaled[n] = v * factor
+	}
+	return scaled
+}
+
+// servingValue returns the raw API string for the nutrient of the serving
+func servingValue(s fatsecret.FoodServing, n Nutrient) string {
+	switch n {
+	case Calories:
+		return s.Calories
+	case Carbohydrate:
+		return s.Carbohydrate
+	case Protein:
+		return s.Protein
+	case Fat:
+		return s.Fat
+	case SaturatedFat:
+		return s.SaturatedFat
+	case PolyunsaturatedFat:
+		return s.PolyunsaturatedFat
+	case MonounsaturatedFat:
+		return s.MonounsaturatedFat
+	case TransFat:
+		return s.TransFat
+	case Cholesterol:
+		return s.Cholesterol
+	case Sodium:
+		return s.Sodium
+	case Potassium:
+		return s.Potassium
+	case Fiber:
+		return s.Fiber
+	case Sugar:
+		return s.Sugar
+	case VitaminA:
+		return s.VitaminA
+	case VitaminC:
+		return s.VitaminC
+	case Calcium:
+		return s.Calcium
+	case Iron:
+		return s.Iron
+	case AddedSugars:
+		return s.AddedSugars
+	case VitaminD:
+		return s.VitaminD
+	case VitaminAMcg:
+		return s.VitaminAMcg
+	case VitaminCMg:
+		return s.VitaminCMg
+	case CalciumMg:
+		return s.CalciumMg
+	case IronMg:
+		return s.IronMg
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package nutrition
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fitzone/fatsecret"
+)
+
+// ConversionError is returned when a quantity cannot be converted into
+// the units of a serving (ie: a volume to a mass without a density)
+type ConversionError struct {
+	From Unit
+	To   Unit
+}
+
+// Error returns the conversion error message
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("Cannot convert '%s' to '%s'", e.From, e.To)
+}
+
+// Factor returns the multiplier which scales the nutrients of the given
+// serving to the requested quantity
+func Factor(s fatsecret.FoodServing, q Quantity) (float64, error) {
+	// whole servings need no conversion
+	if q.Unit == UnitServing {
+		return q.Amount, nil
+	}
+
+	// if the quantity uses the serving's own measurement (ie: "slice")
+	units := parseDecimal(s.NumberOfUnits)
+	if units <= 0 {
+		units = 1
+	}
+	measure := NormalizeUnit(s.MeasurementDescription)
+	if q.Unit == measure {
+		return q.Amount / units, nil
+	}
+
+	// the remaining conversions need a mass or volume quantity
+	qBase, qDim := q.base()
+
+	// convert using the metric serving amount (ie: "100 g")
+	metricUnit := NormalizeUnit(s.MetricServingUnit)
+	metric := Quantity{Amount: parseDecimal(s.MetricServingAmount), Unit: metricUnit}
+	if mBase, mDim := metric.base(); qDim != DimensionNone && mDim == qDim && mBase > 0 {
+		return qBase / mBase, nil
+	}
+
+	// convert using the serving measurement (ie: "1 cup" to "tbsp")
+	serving := Quantity{Amount: units, Unit: measure}
+	if sBase, sDim := serving.base(); qDim != DimensionNone && sDim == qDim && sBase > 0 {
+		return qBase / sBase, nil
+	}
+
+	// report the conversion error against the most specific serving unit
+	to := metricUnit
+	if to == "" {
+		to = measure
+	}
+	return 0, &ConversionError{From: q.Unit, To: to}
+}
+
+// Scale returns the nutrient profile of the given serving scaled to
+// the requested quantity
+func Scale(s fatsecret.FoodServing, q Quantity) (Profile, error) {
+	// determine the scaling factor
+	factor, err := Factor(s, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse and scale the serving nutrients
+	p, err := ProfileOf(s)
+	if err != nil {
+		return nil, err
+	}
+	return p.Scale(factor), nil
+}
+
+// BestServing returns the serving of the food which best matches the
+// unit of the requested quantity. Servings measured in the same unit
+// are preferred, then servings with a convertible metric amount.
+func BestServing(f *fatsecret.FoodInfo, q Quantity) (fatsecret.FoodServing, error) {
+	// if the food has no servings
+	if f == nil || len(f.Servings.Serving) == 0 {
+		return fatsecret.FoodServing{}, errors.New("Food has no servings")
+	}
+
+	// whole servings use the default serving flagged by the v4 methods,
+	// or else the first serving
+	if q.Unit == UnitServing {
+		if s := f.DefaultServing(); s != nil {
+			return *s, nil
+		}
+		return f.Servings.Serving[0], nil
+	}
+
+	// rank each serving by how directly it converts
+	best, bestRank := -1, 0
+	var convErr error
+	for i, s := range f.Servings.Serving {
+		rank := 0
+		switch {
+		case NormalizeUnit(s.MeasurementDescription) == q.Unit:
+			rank = 3
+		case NormalizeUnit(s.MetricServingUnit) == q.Unit:
+			rank = 2
+		default:
+			if _, err := Factor(s, q); err != nil {
+				convErr = err
+				continue
+			}
+			rank = 1
+		}
+		if rank > bestRank {
+			best, bestRank = i, rank
+		}
+	}
+
+	// if no serving can be converted to the quantity unit
+	if best < 0 {
+		return fatsecret.FoodServing{}, convErr
+	}
+	return f.Servings.Serving[best], nil
+}
+
+// ScaleFood picks the best serving of the food for the requested
+// quantity and returns its scaled nutrient profile
+func ScaleFood(f *fatsecret.FoodInfo, q Quantity) (Profile, error) {
+	s, err := BestServing(f, q)
+	if err != nil {
+		return nil, err
+	}
+	return Scale(s, q)
+}
+
+// parseDecimal parses an API decimal string, returning zero if invalid
+func parseDecimal(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
@@ -0,0 +1,165 @@
+package nutrition
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/fitzone/fatsecret"
+)
+
+var (
+	// 1 cup of whole milk
+	milkCup = fatsecret.FoodServing{
+		ServingID:              "1",
+		ServingDescription:     "1 cup",
+		MetricServingAmount:    "244.000",
+		MetricServingUnit:      "g",
+		NumberOfUnits:          "1.000",
+		MeasurementDescription: "cup",
+		Calories:               "149",
+		Protein:                "7.69",
+		Fat:                    "7.93",
+	}
+
+	// 2 slices of bread
+	breadSlices = fatsecret.FoodServing{
+		ServingID:              "2",
+		ServingDescription:     "2 slices",
+		MetricServingAmount:    "56.000",
+		MetricServingUnit:      "g",
+		NumberOfUnits:          "2.000",
+		MeasurementDescription: "slice",
+		Calories:               "150",
+	}
+
+	// 100 ml of juice
+	juice100ml = fatsecret.FoodServing{
+		ServingID:              "3",
+		ServingDescription:     "100 ml",
+		MetricServingAmount:    "100.000",
+		MetricServingUnit:      "ml",
+		NumberOfUnits:          "100.000",
+		MeasurementDescription: "ml",
+		Calories:               "45",
+	}
+)
+
+func TestParseQuantity(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		s    string
+		want Quantity
+	}{
+		{"150 g", Quantity{150, UnitGram}},
+		{"150g", Quantity{150, UnitGram}},
+		{"2.5 cups", Quantity{2.5, UnitCup}},
+		{"3 servings", Quantity{3, UnitServing}},
+		{"3", Quantity{3, UnitServing}},
+		{"8 Fl Oz", Quantity{8, UnitFluidOunce}},
+		{"2 slices", Quantity{2, Unit("slice")}},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.s, func(t *testing.T) {
+			q, err := ParseQuantity(tc.s)
+			if err != nil {
+				t.Fatalf("Could not parse quantity: '%v'", err)
+			}
+			if q != tc.want {
+				t.Errorf("got %+v; want %+v", q, tc.want)
+			}
+		})
+	}
+}
+
+func TestScale(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		name     string
+		serving  fatsecret.FoodServing
+		quantity Quantity
+		calories float64
+	}{
+		{"servings", milkCup, Quantity{3, UnitServing}, 447},
+		{"same measurement", milkCup, Quantity{2.5, UnitCup}, 372.5},
+		{"metric mass", milkCup, Quantity{122, UnitGram}, 74.5},
+		{"imperial mass", milkCup, Quantity{244 / 28.349523125, UnitOunce}, 149},
+		{"measurement per unit", breadSlices, Quantity{1, Unit("slice")}, 75},
+		{"metric volume", juice100ml, Quantity{1, UnitCup}, 45 * 2.365882365},
+		{"fluid ounces", juice100ml, Quantity{8, UnitFluidOunce}, 45 * 2.365882365},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Scale(tc.serving, tc.quantity)
+			if err != nil {
+				t.Fatalf("Could not scale serving: '%v'", err)
+			}
+			if got := p[Calories]; math.Abs(got-tc.calories) > 0.001 {
+				t.Errorf("got %v calories; want %v", got, tc.calories)
+			}
+		})
+	}
+}
+
+func TestScaleConversionError(t *testing.T) {
+	// volume to mass without a density cannot be converted
+	_, err := Scale(breadSlices, Quantity{1, UnitCup})
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) {
+		t.Fatalf("got '%v'; want a *ConversionError", err)
+	}
+	if convErr.From != UnitCup || convErr.To != UnitGram {
+		t.Errorf("got %+v; want cup to g", convErr)
+	}
+}
+
+func TestBestServing(t *testing.T) {
+	food := &fatsecret.FoodInfo{
+		Servings: fatsecret.FoodServings{
+			Serving: []fatsecret.FoodServing{breadSlices, milkCup, juice100ml},
+		},
+	}
+
+	// define the test-cases
+	testCases := []struct {
+		quantity  Quantity
+		servingID string
+	}{
+		{Quantity{1, UnitServing}, "2"},
+		{Quantity{2, UnitCup}, "1"},
+		{Quantity{1, Unit("slice")}, "2"},
+		{Quantity{50, UnitGram}, "2"},
+		{Quantity{250, UnitMilliliter}, "3"},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.quantity.String(), func(t *testing.T) {
+			s, err := BestServing(food, tc.quantity)
+			if err != nil {
+				t.Fatalf("Could not pick serving: '%v'", err)
+			}
+			if s.ServingID != tc.servingID {
+				t.Errorf("got serving '%s'; want '%s'", s.ServingID, tc.servingID)
+			}
+		})
+	}
+	// the flagged default serving is used for whole servings
+	flagged := juice100ml
+	flagged.IsDefault = "1"
+	food.Servings.Serving = []fatsecret.FoodServing{breadSlices, milkCup, flagged}
+	s, err := BestServing(food, Quantity{1, UnitServing})
+	if err != nil {
+		t.Fatalf("Could not pick serving: '%v'", err)
+	}
+	if s.ServingID != "3" {
+		t.Errorf("got serving '%s'; want the default serving '3'", s.ServingID)
+	}
+}
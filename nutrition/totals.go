@@ -0,0 +1,139 @@
+package nutrition
+
+import (
+	"github.com/fitzone/fatsecret"
+)
+
+const (
+	// energy per gram of each macro-nutrient, in kcal
+	kcalPerGramCarbohydrate = 4
+	kcalPerGramProtein      = 4
+	kcalPerGramFat          = 9
+)
+
+// Source is implemented by anything which can report the nutrients it
+// contributes to a total, such as a food diary entry
+type Source interface {
+	Nutrients() (Profile, error)
+}
+
+// Totals accumulates the nutrients of a meal or a day's diary.
+// The zero value is an empty total ready to use.
+type Totals struct {
+	profile Profile
+	missing map[Nutrient]int
+	count   int
+}
+
+// MacroBreakdown is the share of calories from each macro-nutrient,
+// as percentages which add up to 100
+type MacroBreakdown struct {
+	Carbohydrate float64 `json:"carbohydrate"`
+	Protein      float64 `json:"protein"`
+	Fat          float64 `json:"fat"`
+}
+
+// Add adds the nutrients of the given number of servings to the totals
+func (t *Totals) Add(s fatsecret.FoodServing, quantity float64) error {
+	p, err := ProfileOf(s)
+	if err != nil {
+		return err
+	}
+	t.AddProfile(p.Scale(quantity))
+	return nil
+}
+
+// AddSource adds the nutrients reported by the given source to the totals
+func (t *Totals) AddSource(src Source) error {
+	p, err := src.Nutrients()
+	if err != nil {
+		return err
+	}
+	t.AddProfile(p)
+	return nil
+}
+
+// AddProfile adds an already scaled nutrient profile to the totals
+func (t *Totals) AddProfile(p Profile) {
+	// lazily create the maps so the zero value can be used
+	if t.profile == nil {
+		t.profile = Profile{}
+		t.missing = map[Nutrient]int{}
+	}
+
+	// sum the available nutrients and count the missing ones
+	for _, n := range Nutrients {
+		if v, ok := p[n]; ok {
+			t.profile[n] += v
+		} else {
+			t.missing[n]++
+		}
+	}
+	t.count++
+}
+
+// Sum returns the combined totals of the given totals (ie: the meals of a day)
+func Sum(totals ...*Totals) *Totals {
+	sum := &Totals{
+		profile: Profile{},
+		missing: map[Nutrient]int{},
+	}
+	for _, t := range totals {
+		if t == nil {
+			continue
+		}
+		for n, v := range t.profile {
+			sum.profile[n] += v
+		}
+		for n, c := range t.missing {
+			sum.missing[n] += c
+		}
+		sum.count += t.count
+	}
+	return sum
+}
+
+// Profile returns a copy of the summed nutrients. Nutrients which none
+// of the inputs reported are missing from the profile.
+func (t *Totals) Profile() Profile {
+	return t.profile.Scale(1)
+}
+
+// Get returns the summed amount of the nutrient and whether any of the
+// inputs reported it
+func (t *Totals) Get(n Nutrient) (float64, bool) {
+	return t.profile.Get(n)
+}
+
+// Count returns the number of inputs added to the totals
+func (t *Totals) Count() int {
+	return t.count
+}
+
+// Partial reports whether some, but not all, of the inputs lacked the
+// nutrient, meaning its total is an underestimate
+func (t *Totals) Partial(n Nutrient) bool {
+	missing := t.missing[n]
+	return missing > 0 && missing < t.count
+}
+
+// Macros returns the percentage of calories from carbohydrate, protein
+// and fat, based on the standard 4/4/9 kcal per gram energy factors
+func (t *Totals) Macros() MacroBreakdown {
+	// calculate the energy of each macro-nutrient
+	carbs := t.profile[Carbohydrate] * kcalPerGramCarbohydrate
+	protein := t.profile[Protein] * kcalPerGramProtein
+	fat := t.profile[Fat] * kcalPerGramFat
+
+	// avoid dividing by zero when there is no energy
+	total := carbs + protein + fat
+	if total <= 0 {
+		return MacroBreakdown{}
+	}
+
+	return MacroBreakdown{
+		Carbohydrate: carbs / total * 100,
+		Protein:      protein / total * 100,
+		Fat:          fat / total * 100,
+	}
+}
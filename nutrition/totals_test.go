@@ -0,0 +1,102 @@
+package nutrition
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fitzone/fatsecret"
+)
+
+func TestTotals(t *testing.T) {
+	// breakfast is two cups of milk and a slice of bread
+	breakfast := &Totals{}
+	if err := breakfast.Add(milkCup, 2); err != nil {
+		t.Fatalf("Could not add serving: '%v'", err)
+	}
+	if err := breakfast.Add(breadSlices, 0.5); err != nil {
+		t.Fatalf("Could not add serving: '%v'", err)
+	}
+
+	// lunch is a glass of juice
+	lunch := &Totals{}
+	if err := lunch.Add(juice100ml, 2.5); err != nil {
+		t.Fatalf("Could not add serving: '%v'", err)
+	}
+
+	// sum the day
+	day := Sum(breakfast, lunch)
+	if day.Count() != 3 {
+		t.Errorf("got %d inputs; want 3", day.Count())
+	}
+	if got, _ := day.Get(Calories); math.Abs(got-(298+75+112.5)) > 0.001 {
+		t.Errorf("got %v calories; want %v", got, 298+75+112.5)
+	}
+
+	// protein is only known for the milk
+	if !day.Partial(Protein) {
+		t.Errorf("got complete protein; want partial")
+	}
+	if day.Partial(Calories) {
+		t.Errorf("got partial calories; want complete")
+	}
+	if _, ok := day.Get(Iron); ok || day.Partial(Iron) {
+		t.Errorf("got iron; want it to be unavailable")
+	}
+}
+
+func TestTotalsV1AndV4(t *testing.T) {
+	// the v1 serving has percentages of the daily values, while the v4
+	// serving has absolute amounts
+	v1 := fatsecret.FoodServing{ServingID: "1", Calories: "100", Calcium: "30", Iron: "10"}
+	v4 := fatsecret.FoodServing{ServingID: "2", Calories: "50", CalciumMg: "300", IronMg: "1.8", VitaminD: "2.5"}
+
+	totals := &Totals{}
+	if err := totals.Add(v1, 1); err != nil {
+		t.Fatalf("Could not add serving: '%v'", err)
+	}
+	if err := totals.Add(v4, 2); err != nil {
+		t.Fatalf("Could not add serving: '%v'", err)
+	}
+
+	// the percentages and the absolute amounts are never mixed
+	testCases := []struct {
+		nutrient Nutrient
+		want     float64
+	}{
+		{Calories, 200},
+		{Calcium, 30},
+		{Iron, 10},
+		{CalciumMg, 600},
+		{IronMg, 3.6},
+		{VitaminD, 5},
+	}
+	for _, tc := range testCases {
+		got, ok := totals.Get(tc.nutrient)
+		if !ok || math.Abs(got-tc.want) > 0.001 {
+			t.Errorf("got %v %s; want %v", got, tc.nutrient, tc.want)
+		}
+	}
+
+	// each is only known for one of the servings
+	for _, n := range []Nutrient{Calcium, Iron, CalciumMg, IronMg} {
+		if !totals.Partial(n) {
+			t.Errorf("got complete %s; want partial", n)
+		}
+	}
+}
+
+func TestTotalsMacros(t *testing.T) {
+	totals := &Totals{}
+	totals.AddProfile(Profile{Carbohydrate: 50, Protein: 25, Fat: 100.0 / 9})
+
+	// 200 + 100 + 100 kcal
+	m := totals.Macros()
+	if math.Abs(m.Carbohydrate-50) > 0.001 || math.Abs(m.Protein-25) > 0.001 || math.Abs(m.Fat-25) > 0.001 {
+		t.Errorf("got %+v; want 50/25/25", m)
+	}
+
+	// empty totals have no breakdown
+	if m := (&Totals{}).Macros(); m != (MacroBreakdown{}) {
+		t.Errorf("got %+v; want an empty breakdown", m)
+	}
+}
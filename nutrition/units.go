@@ -0,0 +1,162 @@
+package nutrition
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Unit is a normalized unit of measure (ie: "g", "cup" or "slice")
+type Unit string
+
+const (
+	// UnitGram is the metric mass unit
+	UnitGram Unit = "g"
+	// UnitKilogram is 1000 grams
+	UnitKilogram Unit = "kg"
+	// UnitOunce is the avoirdupois ounce
+	UnitOunce Unit = "oz"
+	// UnitPound is 16 ounces
+	UnitPound Unit = "lb"
+	// UnitMilliliter is the metric volume unit
+	UnitMilliliter Unit = "ml"
+	// UnitLiter is 1000 milliliters
+	UnitLiter Unit = "l"
+	// UnitFluidOunce is the US fluid ounce
+	UnitFluidOunce Unit = "fl oz"
+	// UnitCup is the US cup
+	UnitCup Unit = "cup"
+	// UnitTablespoon is the US tablespoon
+	UnitTablespoon Unit = "tbsp"
+	// UnitTeaspoon is the US teaspoon
+	UnitTeaspoon Unit = "tsp"
+	// UnitServing is a whole FatSecret serving
+	UnitServing Unit = "serving"
+)
+
+// Dimension is the physical dimension of a unit
+type Dimension int
+
+const (
+	// DimensionNone is used for units which cannot be converted (ie: "slice")
+	DimensionNone Dimension = iota
+	// DimensionMass is used for mass units, based on grams
+	DimensionMass
+	// DimensionVolume is used for volume units, based on milliliters
+	DimensionVolume
+)
+
+// unitBases are the dimension and base amount (g or ml) of each unit
+var unitBases = map[Unit]struct {
+	dim  Dimension
+	base float64
+}{
+	UnitGram:       {DimensionMass, 1},
+	UnitKilogram:   {DimensionMass, 1000},
+	UnitOunce:      {DimensionMass, 28.349523125},
+	UnitPound:      {DimensionMass, 453.59237},
+	UnitMilliliter: {DimensionVolume, 1},
+	UnitLiter:      {DimensionVolume, 1000},
+	UnitFluidOunce: {DimensionVolume, 29.5735295625},
+	UnitCup:        {DimensionVolume, 236.5882365},
+	UnitTablespoon: {DimensionVolume, 14.78676478125},
+	UnitTeaspoon:   {DimensionVolume, 4.92892159375},
+}
+
+// unitAliases maps the common spellings of each unit
+var unitAliases = map[string]Unit{
+	"g": UnitGram, "gr": UnitGram, "gram": UnitGram, "grams": UnitGram,
+	"kg": UnitKilogram, "kilogram": UnitKilogram, "kilograms": UnitKilogram,
+	"oz": UnitOunce, "ounce": UnitOunce, "ounces": UnitOunce,
+	"lb": UnitPound, "lbs": UnitPound, "pound": UnitPound, "pounds": UnitPound,
+	"ml": UnitMilliliter, "milliliter": UnitMilliliter, "milliliters": UnitMilliliter,
+	"millilitre": UnitMilliliter, "millilitres": UnitMilliliter,
+	"l": UnitLiter, "liter": UnitLiter, "liters": UnitLiter, "litre": UnitLiter, "litres": UnitLiter,
+	"fl oz": UnitFluidOunce, "floz": UnitFluidOunce, "fl. oz": UnitFluidOunce,
+	"fluid ounce": UnitFluidOunce, "fluid ounces": UnitFluidOunce,
+	"cup": UnitCup, "cups": UnitCup,
+	"tbsp": UnitTablespoon, "tbs": UnitTablespoon, "tablespoon": UnitTablespoon, "tablespoons": UnitTablespoon,
+	"tsp": UnitTeaspoon, "teaspoon": UnitTeaspoon, "teaspoons": UnitTeaspoon,
+	"serving": UnitServing, "servings": UnitServing, "serve": UnitServing, "serves": UnitServing,
+}
+
+// NormalizeUnit converts the given unit or measurement description into
+// its normalized form. Units without a known alias are lower-cased and
+// made singular (ie: "Slices" becomes "slice").
+func NormalizeUnit(s string) Unit {
+	// drop any qualifiers (ie: "cup, chopped" or "serving (100g)")
+	if i := strings.IndexAny(s, ",("); i >= 0 {
+		s = s[:i]
+	}
+
+	// lower-case and collapse the whitespace
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	s = strings.TrimSuffix(s, ".")
+
+	// if the unit is a known alias
+	if u, ok := unitAliases[s]; ok {
+		return u
+	}
+
+	// make the unknown unit singular
+	if len(s) > 3 && strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss") {
+		s = s[:len(s)-1]
+	}
+	return Unit(s)
+}
+
+// LookupUnit returns the unit of a known spelling (ie: "tablespoons"),
+// and false for words which are not a known unit
+func LookupUnit(s string) (Unit, bool) {
+	u, ok := unitAliases[strings.ToLower(strings.Join(strings.Fields(s), " "))]
+	return u, ok
+}
+
+// Dimension returns the physical dimension of the unit
+func (u Unit) Dimension() Dimension {
+	return unitBases[u].dim
+}
+
+// Quantity is an amount of a unit (ie: 150 g or 2.5 cups)
+type Quantity struct {
+	Amount float64
+	Unit   Unit
+}
+
+// ParseQuantity parses a quantity string such as "150 g", "150g",
+// "2.5 cups" or "3 servings". A missing unit means servings.
+func ParseQuantity(s string) (Quantity, error) {
+	s = strings.TrimSpace(s)
+
+	// split the leading number from the unit
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	amount, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || amount < 0 {
+		return Quantity{}, fmt.Errorf("Invalid quantity '%s' given", s)
+	}
+
+	// default to servings when no unit is given
+	unit := UnitServing
+	if rest := strings.TrimSpace(s[i:]); rest != "" {
+		unit = NormalizeUnit(rest)
+	}
+
+	return Quantity{Amount: amount, Unit: unit}, nil
+}
+
+// String returns the quantity as "<amount> <unit>"
+func (q Quantity) String() string {
+	return fmt.Sprintf("%s %s", strconv.FormatFloat(q.Amount, 'f', -1, 64), q.Unit)
+}
+
+// base returns the quantity in the base unit (g or ml) of its dimension
+func (q Quantity) base() (float64, Dimension) {
+	b, ok := unitBases[q.Unit]
+	if !ok {
+		return 0, DimensionNone
+	}
+	return q.Amount * b.base, b.dim
+}
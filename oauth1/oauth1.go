@@ -0,0 +1,254 @@
+/*
+Package oauth1 implements the RFC 5849 Oauth1 signature base string,
+HMAC-SHA1 signing and signature verification used by the FatSecret API.
+
+The functions are deterministic: the timestamp and nonce are ordinary
+parameters chosen by the caller, so signatures can be tested against
+the published RFC vectors.
+*/
+package oauth1
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	// SignatureMethodHMACSHA1 is the only signature method supported
+	SignatureMethodHMACSHA1 = "HMAC-SHA1"
+
+	// the name of the signature parameter, which is never signed
+	signatureParam = "oauth_signature"
+)
+
+var (
+	// ErrMissingSignature is returned when a request has no signature
+	ErrMissingSignature = errors.New("Missing oauth_signature parameter")
+	// ErrUnsupportedSignatureMethod is returned for signature methods other than HMAC-SHA1
+	ErrUnsupportedSignatureMethod = errors.New("Unsupported oauth_signature_method")
+	// ErrInvalidSignature is returned when a request signature does not match
+	ErrInvalidSignature = errors.New("Invalid oauth_signature")
+)
+
+// PercentEncode encodes the string as defined by RFC 5849 section 3.6.
+// Only the unreserved characters (ALPHA, DIGIT, '-', '.', '_' and '~')
+// are kept; all other UTF-8 bytes are encoded as upper-case '%XX'.
+func PercentEncode(s string) string {
+	const hex = "0123456789ABCDEF"
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		if isUnreserved(b) {
+			buf.WriteByte(b)
+			continue
+		}
+		buf.WriteByte('%')
+		buf.WriteByte(hex[b>>4])
+		buf.WriteByte(hex[b&0x0f])
+	}
+	return buf.String()
+}
+
+// NormalizeParams returns the normalized request parameters of RFC 5849
+// section 3.4.1.3.2. The names and values are encoded, sorted by name and
+// then value (so duplicate names are kept), and joined with '&'. The
+// oauth_signature parameter is excluded.
+func NormalizeParams(params url.Values) string {
+	// encode each name/value pair
+	type pair struct{ k, v string }
+	pairs := []pair{}
+	for k, values := range params {
+		if k == signatureParam {
+			continue
+		}
+		for _, v := range values {
+			pairs = append(pairs, pair{PercentEncode(k), PercentEncode(v)})
+		}
+	}
+
+	// sort by the encoded name, then the encoded value
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].k != pairs[j].k {
+			return pairs[i].k < pairs[j].k
+		}
+		return pairs[i].v < pairs[j].v
+	})
+
+	// join the pairs
+	var buf bytes.Buffer
+	for i, p := range pairs {
+		if i > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(p.k)
+		buf.WriteByte('=')
+		buf.WriteString(p.v)
+	}
+	return buf.String()
+}
+
+// BaseURL returns the base string URI of RFC 5849 section 3.4.1.2. The
+// scheme and host are lower-cased, default ports are removed and the
+// query and fragment are dropped.
+func BaseURL(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Host)
+
+	// remove the default port of the scheme
+	if scheme == "http" && strings.HasSuffix(host, ":80") {
+		host = strings.TrimSuffix(host, ":80")
+	} else if scheme == "https" && strings.HasSuffix(host, ":443") {
+		host = strings.TrimSuffix(host, ":443")
+	}
+
+	// keep the path as it was encoded by the request
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return scheme + "://" + host + path
+}
+
+// BaseString returns the signature base string of RFC 5849 section 3.4.1
+// for the http method, URL and request parameters. Any query parameters
+// of the URL must be included in params.
+func BaseString(method string, u *url.URL, params url.Values) string {
+	return strings.ToUpper(method) + "&" +
+		PercentEncode(BaseURL(u)) + "&" +
+		PercentEncode(NormalizeParams(params))
+}
+
+// HMACSHA1 signs the base string as defined by RFC 5849 section 3.4.2 and
+// returns the base64 signature. The token secret is empty for two-legged
+// requests.
+func HMACSHA1(base string, consumerSecret string, tokenSecret string) string {
+	key := PercentEncode(consumerSecret) + "&" + PercentEncode(tokenSecret)
+	mac := hmac.New(sha1.New, []byte(key))
+	mac.Write([]byte(base))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// EncodeQuery encodes the parameters as a URL query string using the
+// RFC 5849 percent-encoding, sorted like the normalized parameters and
+// with any signature last
+func EncodeQuery(params url.Values) string {
+	// include the signature, unlike the normalized parameters
+	query := NormalizeParams(params)
+	if sig, ok := params[signatureParam]; ok {
+		for _, v := range sig {
+			if query != "" {
+				query += "&"
+			}
+			query += signatureParam + "=" + PercentEncode(v)
+		}
+	}
+	return query
+}
+
+// RequestURL returns the URL of a received server request, using the
+// Host header and whether TLS was used to fill in the scheme and host
+func RequestURL(r *http.Request) *url.URL {
+	u := *r.URL
+	if u.Host == "" {
+		u.Host = r.Host
+	}
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	return &u
+}
+
+// RequestParams collects the request parameters of RFC 5849 section 3.4.1.3
+// from the query, a form-encoded body and the 'OAuth' Authorization header.
+// The body is restored so it can still be read by the caller.
+func RequestParams(r *http.Request) (url.Values, error) {
+	params := url.Values{}
+
+	// add the query parameters
+	for k, v := range r.URL.Query() {
+		params[k] = append(params[k], v...)
+	}
+
+	// add the form-encoded body parameters
+	if r.Body != nil && strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range form {
+			params[k] = append(params[k], v...)
+		}
+	}
+
+	// add the authorization header parameters, except the realm
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "OAuth ") {
+		for _, part := range strings.Split(auth[len("OAuth "):], ",") {
+			tokens := strings.SplitN(strings.TrimSpace(part), "=", 2)
+			if len(tokens) != 2 || tokens[0] == "realm" {
+				continue
+			}
+			k, err := url.PathUnescape(tokens[0])
+			if err != nil {
+				return nil, err
+			}
+			v, err := url.PathUnescape(strings.Trim(tokens[1], `"`))
+			if err != nil {
+				return nil, err
+			}
+			params[k] = append(params[k], v)
+		}
+	}
+
+	return params, nil
+}
+
+// Verify checks the HMAC-SHA1 signature of a received request against
+// the given secrets. The token secret is empty for two-legged requests.
+func Verify(r *http.Request, consumerSecret string, tokenSecret string) error {
+	// collect the signed parameters
+	params, err := RequestParams(r)
+	if err != nil {
+		return err
+	}
+
+	// check the signature parameters
+	sig := params.Get(signatureParam)
+	if sig == "" {
+		return ErrMissingSignature
+	}
+	if params.Get("oauth_signature_method") != SignatureMethodHMACSHA1 {
+		return ErrUnsupportedSignatureMethod
+	}
+
+	// compare against the expected signature
+	want := HMACSHA1(BaseString(r.Method, RequestURL(r), params), consumerSecret, tokenSecret)
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// isUnreserved reports whether the byte is an RFC 3986 unreserved character
+func isUnreserved(b byte) bool {
+	return 'A' <= b && b <= 'Z' ||
+		'a' <= b && b <= 'z' ||
+		'0' <= b && b <= '9' ||
+		b == '-' || b == '.' || b == '_' || b == '~'
+}
@@ -0,0 +1,211 @@
+package oauth1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// the example request of RFC 5849 section 3.4.1
+func rfcExampleRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/request?b5=%3D%253D&a3=a&c%40=&a2=r%20b", strings.NewReader("c2&a3=2+q"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Authorization", `OAuth realm="Example",`+
+		`oauth_consumer_key="9djdj82h48djs9d2",`+
+		`oauth_token="kkk9d7dh3k39sjv7",`+
+		`oauth_signature_method="HMAC-SHA1",`+
+		`oauth_timestamp="137131201",`+
+		`oauth_nonce="7d8f3e4a",`+
+		`oauth_signature="bYT5CMsGcbgUdFHObYMEfcx6bsw%3D"`)
+	return r
+}
+
+func TestPercentEncode(t *testing.T) {
+	// define the test-cases (from the Oauth test case vectors)
+	testCases := []struct {
+		s    string
+		want string
+	}{
+		{"abcABC123", "abcABC123"},
+		{"-._~", "-._~"},
+		{"%", "%25"},
+		{"+", "%2B"},
+		{"&=*", "%26%3D%2A"},
+		{"\u000A", "%0A"},
+		{" ", "%20"},
+		{"\u007F", "%7F"},
+		{"\u0080", "%C2%80"},
+		{"、", "%E3%80%81"},
+		{"\\", "%5C"},
+		{"'!()", "%27%21%28%29"},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.want, func(t *testing.T) {
+			if got := PercentEncode(tc.s); got != tc.want {
+				t.Errorf("got '%s'; want '%s'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeParams(t *testing.T) {
+	// RFC 5849 section 3.4.1.3.2
+	params, err := RequestParams(rfcExampleRequest())
+	if err != nil {
+		t.Fatalf("Could not collect params: '%v'", err)
+	}
+	want := "a2=r%20b&a3=2%20q&a3=a&b5=%3D%253D&c%40=&c2=&oauth_consumer_key=9djdj82h48djs9d2" +
+		"&oauth_nonce=7d8f3e4a&oauth_signature_method=HMAC-SHA1&oauth_timestamp=137131201" +
+		"&oauth_token=kkk9d7dh3k39sjv7"
+	if got := NormalizeParams(params); got != want {
+		t.Errorf("got '%s'; want '%s'", got, want)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	// define the test-cases (RFC 5849 section 3.4.1.2)
+	testCases := []struct {
+		url  string
+		want string
+	}{
+		{"HTTP://EXAMPLE.COM:80/r%20v/X?id=123", "http://example.com/r%20v/X"},
+		{"https://www.example.net:8080/?q=1", "https://www.example.net:8080/"},
+		{"https://www.example.net:443", "https://www.example.net/"},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.url, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatalf("Could not parse url: '%v'", err)
+			}
+			if got := BaseURL(u); got != tc.want {
+				t.Errorf("got '%s'; want '%s'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBaseString(t *testing.T) {
+	// RFC 5849 section 3.4.1.1
+	r := rfcExampleRequest()
+	params, err := RequestParams(r)
+	if err != nil {
+		t.Fatalf("Could not collect params: '%v'", err)
+	}
+	want := "POST&http%3A%2F%2Fexample.com%2Frequest&a2%3Dr%2520b%26a3%3D2%2520q" +
+		"%26a3%3Da%26b5%3D%253D%25253D%26c%2540%3D%26c2%3D%26oauth_consumer_" +
+		"key%3D9djdj82h48djs9d2%26oauth_nonce%3D7d8f3e4a%26oauth_signature_m" +
+		"ethod%3DHMAC-SHA1%26oauth_timestamp%3D137131201%26oauth_token%3Dkkk" +
+		"9d7dh3k39sjv7"
+	if got := BaseString(r.Method, RequestURL(r), params); got != want {
+		t.Errorf("got '%s'; want '%s'", got, want)
+	}
+}
+
+func TestHMACSHA1(t *testing.T) {
+	// define the test-cases (RFC 5849 section 1.2)
+	testCases := []struct {
+		name           string
+		method         string
+		url            string
+		params         url.Values
+		consumerSecret string
+		tokenSecret    string
+		want           string
+	}{
+		{
+			"temporary credentials",
+			"POST",
+			"https://photos.example.net/initiate",
+			url.Values{
+				"oauth_consumer_key":     {"dpf43f3p2l4k3l03"},
+				"oauth_signature_method": {"HMAC-SHA1"},
+				"oauth_timestamp":        {"137131200"},
+				"oauth_nonce":            {"wIjqoS"},
+				"oauth_callback":         {"http://printer.example.com/ready"},
+			},
+			"kd94hf93k423kf44",
+			"",
+			"74KNZJeDHnMBp0EMJ9ZHt/XKycU=",
+		},
+		{
+			"protected resource",
+			"GET",
+			"http://photos.example.net/photos",
+			url.Values{
+				"file":                   {"vacation.jpg"},
+				"size":                   {"original"},
+				"oauth_consumer_key":     {"dpf43f3p2l4k3l03"},
+				"oauth_token":            {"nnch734d00sl2jdk"},
+				"oauth_signature_method": {"HMAC-SHA1"},
+				"oauth_timestamp":        {"137131202"},
+				"oauth_nonce":            {"chapoH"},
+			},
+			"kd94hf93k423kf44",
+			"pfkkdhi9sl3r4s00",
+			"MdpQcU8iPSUjWoN/UDMsK2sui9I=",
+		},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatalf("Could not parse url: '%v'", err)
+			}
+			got := HMACSHA1(BaseString(tc.method, u, tc.params), tc.consumerSecret, tc.tokenSecret)
+			if got != tc.want {
+				t.Errorf("got '%s'; want '%s'", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	// sign a request with duplicate and unicode parameters
+	u, _ := url.Parse("http://platform.example.com/rest/server.api")
+	params := url.Values{
+		"method":                 {"foods.search"},
+		"search_expression":      {"crème brûlée & *"},
+		"tag":                    {"b", "a"},
+		"oauth_consumer_key":     {"key"},
+		"oauth_signature_method": {"HMAC-SHA1"},
+		"oauth_timestamp":        {"1500000000"},
+		"oauth_nonce":            {"42"},
+	}
+	params.Set("oauth_signature", HMACSHA1(BaseString("GET", u, params), "secret", ""))
+	u.RawQuery = EncodeQuery(params)
+
+	// the signature verifies with the right secret
+	r := httptest.NewRequest(http.MethodGet, u.String(), nil)
+	if err := Verify(r, "secret", ""); err != nil {
+		t.Errorf("got '%v'; want a valid signature", err)
+	}
+
+	// the signature fails with the wrong secret
+	if err := Verify(r, "wrong", ""); err != ErrInvalidSignature {
+		t.Errorf("got '%v'; want ErrInvalidSignature", err)
+	}
+
+	// a tampered parameter fails
+	tampered := httptest.NewRequest(http.MethodGet, strings.Replace(u.String(), "foods.search", "food.get", 1), nil)
+	if err := Verify(tampered, "secret", ""); err != ErrInvalidSignature {
+		t.Errorf("got '%v'; want ErrInvalidSignature", err)
+	}
+
+	// an unsigned request fails
+	unsigned := httptest.NewRequest(http.MethodGet, "http://platform.example.com/rest/server.api?method=food.get", nil)
+	if err := Verify(unsigned, "secret", ""); err != ErrMissingSignature {
+		t.Errorf("got '%v'; want ErrMissingSignature", err)
+	}
+}
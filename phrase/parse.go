@@ -0,0 +1,326 @@
+/*
+Package phrase resolves free-text food phrases, such as "2 cups skim milk"
+or "½ avocado", into FatSecret foods with their nutrients scaled to the
+requested quantity.
+
+	resolver := phrase.NewResolver(client, 0)
+	result, err := resolver.Resolve(ctx, "2 cups skim milk")
+	fmt.Println(result.Food.Name, result.Nutrients[nutrition.Calories], result.Confidence)
+
+The resolver uses the fatsecret.FoodService interface, so it can be tested
+against a fatsecrettest.Fake.
+*/
+package phrase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fitzone/fatsecret/nutrition"
+)
+
+// Phrase is a parsed food phrase (ie: "2 cups skim milk")
+type Phrase struct {
+	Text     string             // the original text
+	Quantity nutrition.Quantity // the requested quantity, one serving when none is given
+	Food     string             // the lower-case food description (ie: "skim milk")
+}
+
+// vulgarFractions are the values of the unicode fraction characters
+var vulgarFractions = map[rune]float64{
+	'½': 1.0 / 2, '⅓': 1.0 / 3, '⅔': 2.0 / 3, '¼': 1.0 / 4, '¾': 3.0 / 4,
+	'⅕': 1.0 / 5, '⅖': 2.0 / 5, '⅗': 3.0 / 5, '⅘': 4.0 / 5, '⅙': 1.0 / 6,
+	'⅚': 5.0 / 6, '⅛': 1.0 / 8, '⅜': 3.0 / 8, '⅝': 5.0 / 8, '⅞': 7.0 / 8,
+}
+
+// numberWords are the amounts which can be written as words
+var numberWords = map[string]float64{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11,
+	"twelve": 12, "dozen": 12, "half": 0.5, "quarter": 0.25,
+}
+
+// countUnits are the units, besides the mass and volume units, which
+// commonly measure a FatSecret serving (ie: "2 slices bread")
+var countUnits = map[nutrition.Unit]bool{
+	"slice": true, "piece": true, "can": true, "bottle": true, "glass": true,
+	"bowl": true, "scoop": true, "clove": true, "stick": true, "handful": true,
+	"pinch": true, "packet": true, "bar": true, "container": true, "small": true,
+	"medium": true, "large": true, "extra large": true,
+}
+
+// fillerWords are the words dropped between the quantity and the food
+var fillerWords = map[string]bool{
+	"of": true,
+}
+
+// conjunctions are the words which, after a number word, show that the
+// number word is part of the food name (ie: "half and half")
+var conjunctions = map[string]bool{
+	"and": true, "or": true, "&": true, "n": true,
+}
+
+// Parse extracts the quantity, unit and food description of a phrase.
+// The amount may be a number (ie: "1.5"), a fraction (ie: "1/2" or "½"),
+// a mixed number (ie: "1 1/2" or "1½") or a word (ie: "a" or "half").
+// A phrase without a unit (ie: "2 eggs") is measured in servings. A
+// leading word which starts with a digit or a sign must be a valid amount.
+func Parse(text string) (Phrase, error) {
+	p := Phrase{Text: text}
+	tokens := tokenize(text)
+
+	// parse the amount, rejecting a numeric word which is not one
+	// (ie: "1e3" or "-1")
+	amount, n := parseAmount(tokens)
+	if n == 0 && len(tokens) > 0 && isNumeric(tokens[0]) {
+		return p, fmt.Errorf("Invalid quantity in phrase '%s'", text)
+	}
+	tokens = tokens[n:]
+
+	// parse the unit, defaulting to servings
+	unit, n := parseUnit(tokens)
+	tokens = tokens[n:]
+	if n == 0 {
+		unit = nutrition.UnitServing
+	}
+
+	// a unit without an amount means one unit (ie: "cup of tea")
+	if amount < 0 {
+		amount = 1
+	}
+	if amount == 0 {
+		return p, fmt.Errorf("Invalid quantity in phrase '%s'", text)
+	}
+	p.Quantity = nutrition.Quantity{Amount: amount, Unit: unit}
+
+	// the remaining words describe the food
+	for len(tokens) > 0 && fillerWords[tokens[0]] {
+		tokens = tokens[1:]
+	}
+	p.Food = strings.Join(tokens, " ")
+	if p.Food == "" {
+		return p, fmt.Errorf("Missing food in phrase '%s'", text)
+	}
+	return p, nil
+}
+
+// ParseMeal splits a meal description into its phrases and parses each
+// of them. Phrases are separated by commas, semicolons, new lines and
+// " + ", or by "and" and "with" when an amount follows, so that
+// "2 eggs and 1 slice toast" is split while "mac and cheese" is not.
+func ParseMeal(text string) ([]Phrase, error) {
+	phrases := []Phrase{}
+	for _, part := range strings.FieldsFunc(strings.ReplaceAll(text, " + ", ","), isMealSeparator) {
+		// split on the joining words which are followed by an amount
+		words := strings.Fields(part)
+		start := 0
+		for i := 1; i < len(words)-1; i++ {
+			joiner := strings.ToLower(words[i])
+			if joiner != "and" && joiner != "with" {
+				continue
+			}
+			if startsPhrase(words[i+1:]) {
+				p, err := Parse(strings.Join(words[start:i], " "))
+				if err != nil {
+					return nil, err
+				}
+				phrases = append(phrases, p)
+				start = i + 1
+			}
+		}
+
+		// skip empty phrases (ie: "eggs,, toast")
+		if start >= len(words) {
+			continue
+		}
+		p, err := Parse(strings.Join(words[start:], " "))
+		if err != nil {
+			return nil, err
+		}
+		phrases = append(phrases, p)
+	}
+
+	// if the meal has no phrases
+	if len(phrases) == 0 {
+		return nil, fmt.Errorf("Missing food in phrase '%s'", text)
+	}
+	return phrases, nil
+}
+
+// startsPhrase reports whether the words start a new phrase of a meal,
+// which is when they start with a numeric word, or with an amount which
+// is followed by a food (so "half and half" is not split)
+func startsPhrase(words []string) bool {
+	tokens := tokenize(strings.Join(words, " "))
+	if len(tokens) > 0 && isNumeric(tokens[0]) {
+		return true
+	}
+	_, n := parseAmount(tokens)
+	return n > 0 && n < len(tokens)
+}
+
+// isMealSeparator reports whether the rune always separates the phrases
+// of a meal
+func isMealSeparator(r rune) bool {
+	return r == ',' || r == ';' || r == '\n'
+}
+
+// tokenize lower-cases the text and splits it into words, separating a
+// leading amount from the word it is attached to (ie: "100g")
+func tokenize(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "⁄", "/")
+	tokens := []string{}
+	for _, field := range strings.Fields(text) {
+		// drop the punctuation around the word
+		field = strings.Trim(field, ".,;:!?\"'()")
+		if field == "" {
+			continue
+		}
+
+		// split a leading amount from the unit it is attached to, keeping
+		// words such as "1e3" whole so they are rejected as amounts
+		if i := strings.IndexFunc(field, func(r rune) bool { return !isAmountRune(r) }); i > 0 && isAmountRune([]rune(field)[0]) {
+			if strings.IndexFunc(field[i:], func(r rune) bool { return !unicode.IsLetter(r) }) < 0 {
+				tokens = append(tokens, field[:i], field[i:])
+				continue
+			}
+		}
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
+
+// isAmountRune reports whether the rune can be part of a numeric amount
+func isAmountRune(r rune) bool {
+	_, fraction := vulgarFractions[r]
+	return fraction || unicode.IsDigit(r) || r == '.' || r == '/'
+}
+
+// isNumeric reports whether the word starts like a number, with an
+// amount rune or a sign (ie: "2", "½" or "-1")
+func isNumeric(word string) bool {
+	r := []rune(word)[0]
+	return isAmountRune(r) || (len(word) > 1 && (r == '-' || r == '+'))
+}
+
+// parseAmount parses the leading amount of the tokens and returns it
+// with the number of tokens used. The amount is -1 when there is none.
+func parseAmount(tokens []string) (float64, int) {
+	if len(tokens) == 0 {
+		return -1, 0
+	}
+
+	// if the amount is written as words (ie: "half a" or "a half")
+	if amount, ok := numberWords[tokens[0]]; ok {
+		if len(tokens) > 1 {
+			next := tokens[1]
+
+			// the number word is part of the food name (ie: "half and
+			// half"), unless it is a mixed number (ie: "one and a half")
+			if conjunctions[next] {
+				if len(tokens) > 3 && next == "and" && (tokens[2] == "a" || tokens[2] == "an") && tokens[3] == "half" {
+					return amount + 0.5, 4
+				}
+				return -1, 0
+			}
+			if amount == 0.5 && (next == "a" || next == "an") {
+				return amount, 2
+			}
+			if (tokens[0] == "a" || tokens[0] == "an") && next != "a" && next != "an" {
+				if value, ok := numberWords[next]; ok {
+					return value, 2
+				}
+			}
+		}
+		return amount, 1
+	}
+
+	// if the amount is a number
+	amount, ok := parseNumber(tokens[0])
+	if !ok {
+		return -1, 0
+	}
+
+	// add the fraction of a mixed number (ie: "1 1/2")
+	if len(tokens) > 1 && amount == float64(int(amount)) {
+		if fraction, ok := parseNumber(tokens[1]); ok && fraction < 1 {
+			return amount + fraction, 2
+		}
+	}
+	return amount, 1
+}
+
+// parseNumber parses a decimal, a fraction (ie: "1/2"), a unicode
+// fraction (ie: "½") or a whole number with a unicode fraction (ie: "1½").
+// Other forms accepted by strconv (ie: "1e3" or "-1") are rejected.
+func parseNumber(s string) (float64, bool) {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return 0, false
+	}
+	for _, r := range runes {
+		if !isAmountRune(r) {
+			return 0, false
+		}
+	}
+
+	// if the number ends in a unicode fraction
+	if fraction, ok := vulgarFractions[runes[len(runes)-1]]; ok {
+		if len(runes) == 1 {
+			return fraction, true
+		}
+		whole, err := strconv.Atoi(string(runes[:len(runes)-1]))
+		if err != nil || whole < 0 {
+			return 0, false
+		}
+		return float64(whole) + fraction, true
+	}
+
+	// if the number is a fraction
+	if numerator, denominator, ok := strings.Cut(s, "/"); ok {
+		n, err := strconv.ParseFloat(numerator, 64)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		d, err := strconv.ParseFloat(denominator, 64)
+		if err != nil || d <= 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+
+	// parse the decimal number
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return 0, false
+	}
+	return f, true
+}
+
+// parseUnit parses the leading unit of the tokens and returns it with
+// the number of tokens used, which is zero when there is no unit
+func parseUnit(tokens []string) (nutrition.Unit, int) {
+	// try the two word units first (ie: "fl oz" or "extra large")
+	if len(tokens) > 1 {
+		words := tokens[0] + " " + tokens[1]
+		if unit, ok := nutrition.LookupUnit(words); ok {
+			return unit, 2
+		}
+		if unit := nutrition.NormalizeUnit(words); countUnits[unit] {
+			return unit, 2
+		}
+	}
+
+	// try the one word units
+	if len(tokens) > 0 {
+		if unit, ok := nutrition.LookupUnit(tokens[0]); ok {
+			return unit, 1
+		}
+		if unit := nutrition.NormalizeUnit(tokens[0]); countUnits[unit] {
+			return unit, 1
+		}
+	}
+	return "", 0
+}
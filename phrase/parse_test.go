@@ -0,0 +1,103 @@
+package phrase
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/fitzone/fatsecret/nutrition"
+)
+
+func TestParse(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		text   string
+		amount float64
+		unit   nutrition.Unit
+		food   string
+	}{
+		{"2 cups skim milk", 2, nutrition.UnitCup, "skim milk"},
+		{"2 Cups of Skim Milk.", 2, nutrition.UnitCup, "skim milk"},
+		{"1.5 tbsp peanut butter", 1.5, nutrition.UnitTablespoon, "peanut butter"},
+		{"1/2 cup rice", 0.5, nutrition.UnitCup, "rice"},
+		{"1 1/2 cups oats", 1.5, nutrition.UnitCup, "oats"},
+		{"½ avocado", 0.5, nutrition.UnitServing, "avocado"},
+		{"1½ cups flour", 1.5, nutrition.UnitCup, "flour"},
+		{"1 ½ cups flour", 1.5, nutrition.UnitCup, "flour"},
+		{"¾cup yogurt", 0.75, nutrition.UnitCup, "yogurt"},
+		{"100g chicken breast", 100, nutrition.UnitGram, "chicken breast"},
+		{"8 fl oz orange juice", 8, nutrition.UnitFluidOunce, "orange juice"},
+		{"2 slices whole wheat bread", 2, nutrition.Unit("slice"), "whole wheat bread"},
+		{"2 large eggs", 2, nutrition.Unit("large"), "eggs"},
+		{"3 eggs", 3, nutrition.UnitServing, "eggs"},
+		{"a banana", 1, nutrition.UnitServing, "banana"},
+		{"half a cup of blueberries", 0.5, nutrition.UnitCup, "blueberries"},
+		{"a dozen almonds", 12, nutrition.UnitServing, "almonds"},
+		{"cup of coffee", 1, nutrition.UnitCup, "coffee"},
+		{"apple", 1, nutrition.UnitServing, "apple"},
+		{"milk", 1, nutrition.UnitServing, "milk"},
+		{"half and half", 1, nutrition.UnitServing, "half and half"},
+		{"1 cup half and half", 1, nutrition.UnitCup, "half and half"},
+		{"one and a half cups rice", 1.5, nutrition.UnitCup, "rice"},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.text, func(t *testing.T) {
+			p, err := Parse(tc.text)
+			if err != nil {
+				t.Fatalf("Could not parse phrase: '%v'", err)
+			}
+			if math.Abs(p.Quantity.Amount-tc.amount) > 1e-9 || p.Quantity.Unit != tc.unit || p.Food != tc.food {
+				t.Errorf("got '%v %s'; want '%v %s %s'", p.Quantity, p.Food, tc.amount, tc.unit, tc.food)
+			}
+		})
+	}
+
+	// invalid phrases are rejected
+	for _, text := range []string{"", "2 cups", "0 eggs", "1/0 cup milk", "1e3 g rice", "-1 cup milk", "+2 eggs", "1.5.2 cups oats"} {
+		if p, err := Parse(text); err == nil {
+			t.Errorf("got '%+v' for '%s'; want an error", p, text)
+		}
+	}
+}
+
+func TestParseMeal(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		text string
+		want []string
+	}{
+		{"2 eggs, 1 slice toast", []string{"eggs", "toast"}},
+		{"2 eggs and 1 slice toast with a cup of coffee", []string{"eggs", "toast", "coffee"}},
+		{"mac and cheese; fish and chips", []string{"mac and cheese", "fish and chips"}},
+		{"oats + 1 cup milk\nbanana", []string{"oats", "milk", "banana"}},
+		{"apple,, pear", []string{"apple", "pear"}},
+		{"coffee with half and half", []string{"coffee with half and half"}},
+		{"1 cup coffee with half and half and 2 eggs", []string{"coffee with half and half", "eggs"}},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.text, func(t *testing.T) {
+			phrases, err := ParseMeal(tc.text)
+			if err != nil {
+				t.Fatalf("Could not parse meal: '%v'", err)
+			}
+			got := []string{}
+			for _, p := range phrases {
+				got = append(got, p.Food)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got '%v'; want '%v'", got, tc.want)
+			}
+		})
+	}
+
+	// a phrase with an invalid quantity fails the meal
+	if phrases, err := ParseMeal("2 eggs and 1e3 g rice"); err == nil {
+		t.Errorf("got '%+v'; want an error", phrases)
+	}
+}
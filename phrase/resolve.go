@@ -0,0 +1,219 @@
+package phrase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/nutrition"
+)
+
+// DefaultCandidates is the number of search results which are fetched
+// and scored when resolving a phrase
+const DefaultCandidates = 3
+
+// ErrNoMatch is returned when no food matching a phrase can be measured
+// in the phrase's quantity
+var ErrNoMatch = errors.New("No food found for phrase")
+
+// the confidence weights of how directly a serving measures a quantity
+const (
+	weightUnit       = 1.0  // the serving uses the quantity's unit (ie: "cup")
+	weightMetric     = 1.0  // the serving's metric amount uses the unit (ie: "g")
+	weightConversion = 0.9  // the quantity is converted (ie: "tbsp" to "cup")
+	weightServing    = 0.85 // the phrase has no unit, so one serving is assumed
+)
+
+// weightRank is the confidence lost for each place a food is below the
+// top search result
+const weightRank = 0.02
+
+// Candidate is a food which may match a phrase, with the serving which
+// best measures the phrase's quantity
+type Candidate struct {
+	Food       *fatsecret.FoodInfo   // the matched food
+	Serving    fatsecret.FoodServing // the serving used to measure the quantity
+	Nutrients  nutrition.Profile     // the nutrients scaled to the phrase's quantity
+	Confidence float64               // the match confidence, from 0 to 1
+}
+
+// Result is the resolution of a phrase into the best matching food, along
+// with the alternative candidates
+type Result struct {
+	Phrase       Phrase
+	Candidate                // the best candidate
+	Alternatives []Candidate // the other candidates, in order of confidence
+}
+
+// Resolver resolves food phrases using the FatSecret food search
+type Resolver struct {
+	foods      fatsecret.FoodService
+	candidates int
+}
+
+// NewResolver creates a resolver which scores the given number of search
+// results for each phrase, or DefaultCandidates when not positive
+func NewResolver(foods fatsecret.FoodService, candidates int) *Resolver {
+	if candidates <= 0 {
+		candidates = DefaultCandidates
+	}
+	return &Resolver{foods: foods, candidates: candidates}
+}
+
+// Resolve parses and resolves a food phrase (ie: "2 cups skim milk")
+func (r *Resolver) Resolve(ctx context.Context, text string) (*Result, error) {
+	p, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return r.ResolvePhrase(ctx, p)
+}
+
+// ResolveMeal parses and resolves each phrase of a meal description
+// (ie: "2 eggs, 1 slice toast")
+func (r *Resolver) ResolveMeal(ctx context.Context, text string) ([]*Result, error) {
+	phrases, err := ParseMeal(text)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*Result, len(phrases))
+	for i, p := range phrases {
+		if results[i], err = r.ResolvePhrase(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// ResolvePhrase searches for the phrase's food, fetches the best named
+// search results (with their default serving flagged) and scales the
+// serving of each which best measures the phrase's quantity. The confidence of each candidate combines how well
+// its name matches, its search rank and how directly its serving
+// measures the quantity.
+func (r *Resolver) ResolvePhrase(ctx context.Context, p Phrase) (*Result, error) {
+	// search for the food
+	items, err := r.foods.FoodSearchWithOptions(ctx, p.Food, fatsecret.FoodSearchOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// rank the search results by how well their name matches
+	ranked := make([]scoredItem, len(items))
+	for i, item := range items {
+		score := nameScore(p.Food, item) - weightRank*float64(i)
+		if score < 0 {
+			score = 0
+		}
+		ranked[i] = scoredItem{item: item, score: score}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	if len(ranked) > r.candidates {
+		ranked = ranked[:r.candidates]
+	}
+
+	// fetch and measure the best named foods
+	candidates := []Candidate{}
+	for _, scored := range ranked {
+		// if the call was cancelled
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		food, err := r.foods.FoodByIDV4(ctx, scored.item.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// skip foods which cannot be measured in the phrase's quantity
+		serving, err := nutrition.BestServing(food, p.Quantity)
+		if err != nil {
+			continue
+		}
+		nutrients, err := nutrition.Scale(serving, p.Quantity)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			Food:       food,
+			Serving:    serving,
+			Nutrients:  nutrients,
+			Confidence: scored.score * servingWeight(serving, p.Quantity),
+		})
+	}
+
+	// if no food could be measured
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w '%s'", ErrNoMatch, p.Text)
+	}
+
+	// the most confident candidate is the match
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return &Result{
+		Phrase:       p,
+		Candidate:    candidates[0],
+		Alternatives: candidates[1:],
+	}, nil
+}
+
+// scoredItem is a search result with its name score
+type scoredItem struct {
+	item  fatsecret.FoodSearchItem
+	score float64
+}
+
+// nameScore returns the Dice coefficient of the words of the food phrase
+// and of the search result's brand and name, from 0 to 1
+func nameScore(food string, item fatsecret.FoodSearchItem) float64 {
+	want := words(food)
+	got := words(item.BrandName + " " + item.Name)
+	if len(want) == 0 || len(got) == 0 {
+		return 0
+	}
+
+	// count the phrase words found in the result
+	common := 0
+	for w := range want {
+		if got[w] {
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(want)+len(got))
+}
+
+// words returns the set of lower-case words of the text, made singular
+// so that "eggs" matches "egg"
+func words(text string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), isWordSeparator) {
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = w[:len(w)-1]
+		}
+		set[w] = true
+	}
+	return set
+}
+
+// isWordSeparator reports whether the rune separates the words of a name
+func isWordSeparator(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+}
+
+// servingWeight returns the confidence weight of how directly the serving
+// measures the quantity
+func servingWeight(s fatsecret.FoodServing, q nutrition.Quantity) float64 {
+	switch {
+	case q.Unit == nutrition.UnitServing:
+		return weightServing
+	case nutrition.NormalizeUnit(s.MeasurementDescription) == q.Unit:
+		return weightUnit
+	case nutrition.NormalizeUnit(s.MetricServingUnit) == q.Unit:
+		return weightMetric
+	}
+	return weightConversion
+}
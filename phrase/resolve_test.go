@@ -0,0 +1,136 @@
+package phrase_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/fatsecrettest"
+	"github.com/fitzone/fatsecret/nutrition"
+	"github.com/fitzone/fatsecret/phrase"
+)
+
+// newMilkFake returns a fake with milk search results
+func newMilkFake() *fatsecrettest.Fake {
+	fake := fatsecrettest.NewFake()
+	fake.AddSearchResults("skim milk",
+		fatsecret.FoodSearchItem{ID: "1", Name: "Chocolate Milk"},
+		fatsecret.FoodSearchItem{ID: "2", Name: "Skim Milk"},
+		fatsecret.FoodSearchItem{ID: "3", Name: "Skim Milk Powder"},
+		fatsecret.FoodSearchItem{ID: "4", Name: "Milk"},
+	)
+	fake.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Chocolate Milk", Servings: fatsecret.FoodServings{
+		Serving: []fatsecret.FoodServing{{ServingID: "10", MeasurementDescription: "cup", NumberOfUnits: "1", Calories: "208"}},
+	}})
+	fake.AddFood(fatsecret.FoodInfo{ID: "2", Name: "Skim Milk", Servings: fatsecret.FoodServings{
+		Serving: []fatsecret.FoodServing{
+			{ServingID: "20", MeasurementDescription: "g", MetricServingAmount: "100", MetricServingUnit: "g", NumberOfUnits: "100", Calories: "34"},
+			{ServingID: "21", MeasurementDescription: "cup", NumberOfUnits: "1", MetricServingAmount: "245", MetricServingUnit: "g", Calories: "83"},
+		},
+	}})
+	fake.AddFood(fatsecret.FoodInfo{ID: "3", Name: "Skim Milk Powder", Servings: fatsecret.FoodServings{
+		Serving: []fatsecret.FoodServing{{ServingID: "30", MeasurementDescription: "tbsp", NumberOfUnits: "1", Calories: "27"}},
+	}})
+	fake.AddFood(fatsecret.FoodInfo{ID: "4", Name: "Milk", Servings: fatsecret.FoodServings{
+		Serving: []fatsecret.FoodServing{{ServingID: "40", MeasurementDescription: "cup", NumberOfUnits: "1", Calories: "149"}},
+	}})
+	return fake
+}
+
+func TestResolve(t *testing.T) {
+	fake := newMilkFake()
+	resolver := phrase.NewResolver(fake, 3)
+
+	// the best named food is matched, using its cup serving
+	result, err := resolver.Resolve(context.Background(), "2 cups skim milk")
+	if err != nil {
+		t.Fatalf("Could not resolve phrase: '%v'", err)
+	}
+	if result.Food.ID != "2" || result.Serving.ServingID != "21" {
+		t.Errorf("got food '%s' serving '%s'; want food '2' serving '21'", result.Food.ID, result.Serving.ServingID)
+	}
+	if calories := result.Nutrients[nutrition.Calories]; math.Abs(calories-166) > 1e-9 {
+		t.Errorf("got %v calories; want 166", calories)
+	}
+	if result.Confidence <= 0 || result.Confidence > 1 {
+		t.Errorf("got confidence %v; want between 0 and 1", result.Confidence)
+	}
+
+	// the other candidates are alternatives, in order of confidence
+	if len(result.Alternatives) != 2 {
+		t.Fatalf("got %d alternatives; want 2", len(result.Alternatives))
+	}
+	for _, alt := range result.Alternatives {
+		if alt.Confidence > result.Confidence {
+			t.Errorf("got alternative '%s' with confidence %v; want at most %v", alt.Food.Name, alt.Confidence, result.Confidence)
+		}
+	}
+	if got := fake.CallCount("FoodByIDV4"); got != 3 {
+		t.Errorf("got %d FoodByIDV4 calls; want 3", got)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	fake := newMilkFake()
+	resolver := phrase.NewResolver(fake, 0)
+
+	// a food without results does not match
+	if _, err := resolver.Resolve(context.Background(), "1 cup unknown"); !errors.Is(err, phrase.ErrNoMatch) {
+		t.Errorf("got '%v'; want ErrNoMatch", err)
+	}
+
+	// a quantity which no serving can measure does not match
+	if _, err := resolver.Resolve(context.Background(), "3 slices skim milk"); !errors.Is(err, phrase.ErrNoMatch) {
+		t.Errorf("got '%v'; want ErrNoMatch", err)
+	}
+
+	// service errors are returned
+	quota := errors.New("quota exceeded")
+	fake.SetError("FoodByIDV4", quota)
+	if _, err := resolver.Resolve(context.Background(), "1 cup skim milk"); err != quota {
+		t.Errorf("got '%v'; want the service error", err)
+	}
+}
+
+func TestResolveMeal(t *testing.T) {
+	fake := newMilkFake()
+	fake.AddSearchResults("banana", fatsecret.FoodSearchItem{ID: "5", Name: "Banana"})
+	fake.AddFood(fatsecret.FoodInfo{ID: "5", Name: "Banana", Servings: fatsecret.FoodServings{
+		Serving: []fatsecret.FoodServing{{ServingID: "50", MeasurementDescription: "medium", NumberOfUnits: "1", Calories: "105"}},
+	}})
+	resolver := phrase.NewResolver(fake, 0)
+
+	results, err := resolver.ResolveMeal(context.Background(), "1 cup skim milk and ½ banana")
+	if err != nil {
+		t.Fatalf("Could not resolve meal: '%v'", err)
+	}
+	if len(results) != 2 || results[0].Food.ID != "2" || results[1].Food.ID != "5" {
+		t.Fatalf("got %+v; want the skim milk and the banana", results)
+	}
+	if calories := results[1].Nutrients[nutrition.Calories]; math.Abs(calories-52.5) > 1e-9 {
+		t.Errorf("got %v banana calories; want 52.5", calories)
+	}
+}
+
+func TestResolveDefaultServing(t *testing.T) {
+	fake := fatsecrettest.NewFake()
+	fake.AddSearchResults("apple", fatsecret.FoodSearchItem{ID: "1", Name: "Apple"})
+	fake.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple", Servings: fatsecret.FoodServings{
+		Serving: []fatsecret.FoodServing{
+			{ServingID: "10", MeasurementDescription: "g", MetricServingAmount: "100", MetricServingUnit: "g", NumberOfUnits: "100", Calories: "52"},
+			{ServingID: "11", MeasurementDescription: "medium", NumberOfUnits: "1", Calories: "95", IsDefault: "1"},
+		},
+	}})
+	resolver := phrase.NewResolver(fake, 0)
+
+	// a whole serving uses the food's default serving
+	result, err := resolver.Resolve(context.Background(), "an apple")
+	if err != nil {
+		t.Fatalf("Could not resolve phrase: '%v'", err)
+	}
+	if result.Serving.ServingID != "11" {
+		t.Errorf("got serving '%s'; want the default serving '11'", result.Serving.ServingID)
+	}
+}
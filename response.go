@@ -0,0 +1,159 @@
+package fatsecret
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultMaxResponseSize is the default limit of a response body in bytes
+	DefaultMaxResponseSize = 4 << 20
+)
+
+// ResponseTooLargeError is returned when a response body exceeds the
+// client's maximum response size
+type ResponseTooLargeError struct {
+	Method string // the API method (ie: "food.get")
+	Limit  int64  // the maximum response size in bytes
+}
+
+// Error returns the error message
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("FatSecret '%s' response exceeds the maximum size of %d bytes", e.Method, e.Limit)
+}
+
+// UnexpectedResponseError is returned, without decoding the body, when the
+// API responds with a non-2xx status or a content type other than the
+// requested format
+type UnexpectedResponseError struct {
+	Method      string // the API method (ie: "food.get")
+	StatusCode  int    // the http status code
+	ContentType string // the response content type
+	Body        []byte // the response body, up to the maximum response size
+}
+
+// Error returns the error message
+func (e *UnexpectedResponseError) Error() string {
+	if e.StatusCode < http.StatusOK || e.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Sprintf("Unexpected http status %d for FatSecret '%s'", e.StatusCode, e.Method)
+	}
+	return fmt.Sprintf("Unexpected content type '%s' for FatSecret '%s'", e.ContentType, e.Method)
+}
+
+// WithMaxResponseSize sets the maximum size of a response body in bytes,
+// which defaults to DefaultMaxResponseSize. Zero or less disables the limit.
+func WithMaxResponseSize(size int64) Option {
+	return func(c *Client) {
+		c.maxResponseSize = size
+	}
+}
+
+// checkResponse verifies the status and content type of the response
+// before its body is decoded
+func (c *Client) checkResponse(apiMethod string, resp *http.Response) error {
+	contentType := resp.Header.Get("Content-Type")
+	respErr := &UnexpectedResponseError{
+		Method:      apiMethod,
+		StatusCode:  resp.StatusCode,
+		ContentType: contentType,
+	}
+
+	// only successful responses are decoded
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return respErr
+	}
+
+	// accept a missing or plain text content type, as sent by some
+	// proxies, or one matching the requested format (ie: an html error
+	// page is rejected)
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return respErr
+	}
+	if mediaType == "text/plain" || strings.Contains(mediaType, c.format.String()) {
+		return nil
+	}
+	return respErr
+}
+
+// limitBody limits the response body to the client's maximum response size
+func (c *Client) limitBody(apiMethod string, body io.Reader) io.Reader {
+	if c.maxResponseSize <= 0 {
+		return body
+	}
+	return &limitedReader{
+		r:         body,
+		remaining: c.maxResponseSize,
+		err:       &ResponseTooLargeError{Method: apiMethod, Limit: c.maxResponseSize},
+	}
+}
+
+// limitedReader reads up to a limit, failing once the limit is exceeded
+// rather than silently truncating the body
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	err       error
+}
+
+// Read reads from the underlying reader, returning the limit error
+// once more than the limit has been read
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, l.err
+	}
+
+	// read at most one byte past the limit to detect exceeding it
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		n = int(l.remaining)
+		l.remaining = -1
+		return n, l.err
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// decodeFrom decodes the response in the client's format straight from
+// the reader
+func (c *Client) decodeFrom(r io.Reader, v interface{}) error {
+	if c.format == FormatXML {
+		return xml.NewDecoder(r).Decode(v)
+	}
+	return json.NewDecoder(r).Decode(v)
+}
+
+// readAll returns a body reader which reads the whole body
+func readAll(body *[]byte) func(io.Reader) error {
+	return func(r io.Reader) error {
+		var err error
+		*body, err = ioutil.ReadAll(r)
+		return err
+	}
+}
+
+// teeRead returns a body reader which also copies the whole body into
+// the buffer, reading past anything the given reader leaves unread
+func teeRead(read func(io.Reader) error, buf *bytes.Buffer) func(io.Reader) error {
+	return func(r io.Reader) error {
+		tee := io.TeeReader(r, buf)
+		if err := read(tee); err != nil {
+			return err
+		}
+		_, err := io.Copy(ioutil.Discard, tee)
+		return err
+	}
+}
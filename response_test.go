@@ -0,0 +1,76 @@
+package fatsecret
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLimitedReader(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		body  string
+		limit int64
+		valid bool
+	}{
+		{"12345", 5, true},
+		{"12345", 6, true},
+		{"123456", 5, false},
+		{"", 1, true},
+		{"12", 1, false},
+		{"123456", 0, true}, // no limit
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		c := &Client{maxResponseSize: tc.limit}
+		got, err := ioutil.ReadAll(c.limitBody("food.get", strings.NewReader(tc.body)))
+		tooLarge := &ResponseTooLargeError{}
+		switch {
+		case tc.valid && err != nil:
+			t.Errorf("got error '%v' for '%s' limited to %d; want no error", err, tc.body, tc.limit)
+		case tc.valid && string(got) != tc.body:
+			t.Errorf("got '%s' limited to %d; want '%s'", got, tc.limit, tc.body)
+		case !tc.valid && !errors.As(err, &tooLarge):
+			t.Errorf("got error '%v' for '%s' limited to %d; want a ResponseTooLargeError", err, tc.body, tc.limit)
+		case !tc.valid && tooLarge.Limit != tc.limit:
+			t.Errorf("got limit %d; want %d", tooLarge.Limit, tc.limit)
+		}
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		format      Format
+		status      int
+		contentType string
+		valid       bool
+	}{
+		{FormatJSON, http.StatusOK, "application/json", true},
+		{FormatJSON, http.StatusOK, "application/json; charset=utf-8", true},
+		{FormatJSON, http.StatusOK, "text/plain; charset=utf-8", true},
+		{FormatJSON, http.StatusOK, "", true},
+		{FormatJSON, http.StatusOK, "text/html", false},
+		{FormatJSON, http.StatusOK, "application/xml", false},
+		{FormatXML, http.StatusOK, "text/xml", true},
+		{FormatXML, http.StatusOK, "application/json", false},
+		{FormatJSON, http.StatusServiceUnavailable, "application/json", false},
+		{FormatJSON, http.StatusNotFound, "text/html", false},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		c := &Client{format: tc.format}
+		resp := &http.Response{StatusCode: tc.status, Header: http.Header{}}
+		if tc.contentType != "" {
+			resp.Header.Set("Content-Type", tc.contentType)
+		}
+		err := c.checkResponse("food.get", resp)
+		if valid := err == nil; valid != tc.valid {
+			t.Errorf("got error '%v' for %v %d '%s'; want valid %v", err, tc.format, tc.status, tc.contentType, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package fatsecret
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WithRetry retries API calls which fail with a transport error or a 5xx
+// status, up to maxRetries times. The wait before each retry grows by
+// backoff (ie: 1x, 2x, 3x the backoff).
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithRateLimit spaces the client's API calls at least interval apart,
+// including concurrent calls
+func WithRateLimit(interval time.Duration) Option {
+	return func(c *Client) {
+		c.rateInterval = interval
+	}
+}
+
+// shouldRetry reports whether the failed attempt should be retried
+func (c *Client) shouldRetry(ctx context.Context, attempt int, status int, err error) bool {
+	// never retry once the context is done
+	if attempt >= c.maxRetries || ctx.Err() != nil {
+		return false
+	}
+	return retryable(status, err)
+}
+
+// retryable reports whether the outcome of an API call is a failure of
+// the API, such as a transport error or a 5xx status, rather than of the
+// request (ie: an invalid locale or a canceled context)
+func retryable(status int, err error) bool {
+	if status >= http.StatusInternalServerError {
+		return true
+	}
+	return isTransportError(err)
+}
+
+// isTransportError reports whether the error is a failure to reach the
+// API, rather than an error of the request
+func isTransportError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	// an invalid API URL is also a *url.Error, from parsing it
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Op != "parse"
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// waitRetry waits before the given retry attempt
+func (c *Client) waitRetry(ctx context.Context, attempt int) error {
+	return sleep(ctx, c.retryBackoff*time.Duration(attempt))
+}
+
+// waitRateLimit waits for the next rate limiter slot and returns the
+// time waited
+func (c *Client) waitRateLimit(ctx context.Context) (time.Duration, error) {
+	if c.rateInterval <= 0 {
+		return 0, nil
+	}
+
+	// reserve the next slot
+	c.rateMu.Lock()
+	now := time.Now()
+	slot := c.rateNext
+	if slot.Before(now) {
+		slot = now
+	}
+	c.rateNext = slot.Add(c.rateInterval)
+	c.rateMu.Unlock()
+
+	// wait for the reserved slot
+	wait := slot.Sub(now)
+	return wait, sleep(ctx, wait)
+}
+
+// sleep waits for the duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,210 @@
+package fatsecret_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fitzone/fatsecret"
+)
+
+// the body of a successful scripted response
+const okBody = `{"food_id": {"value": "1"}}`
+
+// abortStatus makes the scripted server drop the connection
+const abortStatus = -1
+
+// newScriptedServer starts a server which answers each attempt with the
+// next status, repeating the last one, and returns it with a function
+// reporting the number of attempts
+func newScriptedServer(t *testing.T, statuses ...int) (*httptest.Server, func() int) {
+	mu := sync.Mutex{}
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		status := statuses[min(attempts, len(statuses)-1)]
+		attempts++
+		mu.Unlock()
+
+		// answer with the scripted status
+		switch {
+		case status == abortStatus:
+			panic(http.ErrAbortHandler)
+		case status == http.StatusOK:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(okBody))
+		default:
+			http.Error(w, http.StatusText(status), status)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts
+	}
+}
+
+func TestRetry(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		attempts   int
+		ok         bool
+	}{
+		{"no retries", []int{http.StatusServiceUnavailable, http.StatusOK}, 0, 1, false},
+		{"recovers", []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}, 3, 3, true},
+		{"retry cap", []int{http.StatusServiceUnavailable}, 2, 3, false},
+		{"transport error", []int{abortStatus, http.StatusOK}, 1, 2, true},
+		{"client error", []int{http.StatusBadRequest, http.StatusOK}, 3, 1, false},
+		{"success", []int{http.StatusOK}, 3, 1, true},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			srv, attempts := newScriptedServer(t, tc.statuses...)
+			client, err := fatsecret.NewClient("test-key", "test-secret",
+				fatsecret.WithAPIURL(srv.URL), fatsecret.WithRetry(tc.maxRetries, time.Millisecond))
+			if err != nil {
+				t.Fatalf("Could not create client: '%v'", err)
+			}
+			body, err := client.InvokeAPIContext(context.Background(), "food.find_id_for_barcode", map[string]string{})
+			if got := attempts(); got != tc.attempts {
+				t.Errorf("got %d attempts; want %d", got, tc.attempts)
+			}
+			if ok := err == nil && string(body) == okBody; ok != tc.ok {
+				t.Errorf("got ('%s', '%v'); want success %v", body, err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	srv, attempts := newScriptedServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK)
+	client, err := fatsecret.NewClient("test-key", "test-secret",
+		fatsecret.WithAPIURL(srv.URL), fatsecret.WithRetry(2, 20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// the waits grow by the backoff (20ms then 40ms)
+	start := time.Now()
+	if _, err := client.InvokeAPIContext(context.Background(), "food.find_id_for_barcode", map[string]string{}); err != nil {
+		t.Fatalf("Could not invoke api: '%v'", err)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("got %v for 2 retries; want at least 60ms", elapsed)
+	}
+	if got := attempts(); got != 3 {
+		t.Errorf("got %d attempts; want 3", got)
+	}
+}
+
+func TestRetryCancel(t *testing.T) {
+	srv, attempts := newScriptedServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	client, err := fatsecret.NewClient("test-key", "test-secret",
+		fatsecret.WithAPIURL(srv.URL), fatsecret.WithRetry(3, time.Hour))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// the retry wait ends with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.InvokeAPIContext(ctx, "food.find_id_for_barcode", map[string]string{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got '%v'; want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("got %v; want the wait cancelled with the context", elapsed)
+	}
+	if got := attempts(); got != 1 {
+		t.Errorf("got %d attempts; want 1", got)
+	}
+}
+
+func TestRetryRequestError(t *testing.T) {
+	// define the test-cases
+	testCases := []struct {
+		name     string
+		apiURL   string
+		callOpts []fatsecret.CallOption
+	}{
+		{"invalid api url", "http://[::1", nil},
+		{"invalid locale", "http://127.0.0.1", []fatsecret.CallOption{fatsecret.ForLocale(fatsecret.Locale{Language: "english"})}},
+	}
+
+	// iterate through each test-case
+	for _, tc := range testCases {
+		// run the next sub-test
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := fatsecret.NewClient("test-key", "test-secret",
+				fatsecret.WithAPIURL(tc.apiURL), fatsecret.WithRetry(3, time.Hour))
+			if err != nil {
+				t.Fatalf("Could not create client: '%v'", err)
+			}
+
+			// the request error is returned at once, rather than retried
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			ctx = fatsecret.WithCallOptions(ctx, tc.callOpts...)
+			_, err = client.InvokeAPIContext(ctx, "foods.search", map[string]string{"search_expression": "apple"})
+			if err == nil || errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("got '%v'; want the request error without retries", err)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	srv, attempts := newScriptedServer(t, http.StatusOK)
+	client, err := fatsecret.NewClient("test-key", "test-secret",
+		fatsecret.WithAPIURL(srv.URL), fatsecret.WithRateLimit(30*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// concurrent calls are spaced by the interval
+	start := time.Now()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.InvokeAPIContext(context.Background(), "food.find_id_for_barcode", map[string]string{}); err != nil {
+				t.Errorf("Could not invoke api: '%v'", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("got %v for 3 calls; want at least 60ms", elapsed)
+	}
+
+	// the rate limiter wait ends with the context
+	slow, err := fatsecret.NewClient("test-key", "test-secret",
+		fatsecret.WithAPIURL(srv.URL), fatsecret.WithRateLimit(time.Hour))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if _, err := slow.InvokeAPIContext(context.Background(), "food.find_id_for_barcode", map[string]string{}); err != nil {
+		t.Fatalf("Could not invoke api: '%v'", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := slow.InvokeAPIContext(ctx, "food.find_id_for_barcode", map[string]string{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got '%v'; want context.DeadlineExceeded", err)
+	}
+	if got := attempts(); got != 4 {
+		t.Errorf("got %d attempts; want 4", got)
+	}
+}
@@ -0,0 +1,39 @@
+package fatsecret
+
+import (
+	"context"
+)
+
+// FoodService is implemented by the FatSecret food API methods of the
+// Client, so that code using them can be tested with a fake
+type FoodService interface {
+	FoodSearch(query string) ([]FoodSearchItem, error)
+	FoodSearchWithOptions(ctx context.Context, query string, opts FoodSearchOptions) ([]FoodSearchItem, error)
+	FoodSearchV3(ctx context.Context, query string, opts FoodSearchV3Options) ([]FoodInfo, error)
+	FoodIDForBarcode(barcode string) (string, error)
+	FoodByID(id string) (*FoodInfo, error)
+	FoodByIDV4(ctx context.Context, id string) (*FoodInfo, error)
+	FoodByBarcode(ctx context.Context, barcode string) (*FoodInfo, error)
+}
+
+// BrandService is implemented by the FatSecret brand API methods of the Client
+type BrandService interface {
+	FoodBrandsByType(brandType BrandType) ([]string, error)
+	FoodBrandsStartingWith(startsWith string) ([]string, error)
+	FoodBrands(ctx context.Context, q BrandQuery) ([]string, error)
+	AllBrands(ctx context.Context, brandType BrandType) ([]string, error)
+}
+
+// CategoryService is implemented by the FatSecret category API methods of the Client
+type CategoryService interface {
+	FoodCategories() ([]FoodCategory, error)
+	FoodSubCategories(id string) ([]string, error)
+	CategoryTree(ctx context.Context) (*CategoryTree, error)
+}
+
+// verify the client implements all of the services
+var (
+	_ FoodService     = (*Client)(nil)
+	_ BrandService    = (*Client)(nil)
+	_ CategoryService = (*Client)(nil)
+)
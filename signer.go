@@ -1,9 +1,7 @@
 package fatsecret
 
 import (
-	"crypto/hmac"
-	"crypto/sha1"
-	"encoding/base64"
+	"github.com/fitzone/fatsecret/oauth1"
 )
 
 // Signer signs Oauth1 messages
@@ -26,15 +24,10 @@ func NewHMACSigner(secret string) *HMACSigner {
 
 // Name returns the signer's Oauth1 algorithm name
 func (s *HMACSigner) Name() string {
-	return "HMAC-SHA1"
+	return oauth1.SignatureMethodHMACSHA1
 }
 
 // Sign calculates the HMAC digest and returns the base64 string
 func (s *HMACSigner) Sign(tokenSecret string, msg string) string {
-	// generate the oauth sha1 base64 signature
-	key := s.ConsumerSecret + "&" + tokenSecret
-	mac := hmac.New(sha1.New, []byte(key))
-	mac.Write([]byte(msg))
-	sigBytes := mac.Sum(nil)
-	return base64.StdEncoding.EncodeToString(sigBytes)
+	return oauth1.HMACSHA1(msg, s.ConsumerSecret, tokenSecret)
 }
@@ -0,0 +1,46 @@
+package fatsecret
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fitzone/fatsecret/oauth1"
+)
+
+func TestSignURL(t *testing.T) {
+	c, err := NewClient("key", "secret")
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// define the test-cases
+	testCases := []map[string]string{
+		{"search_expression": "coffee"},
+		{"search_expression": "crème brûlée"},
+		{"starts_with": "*"},
+		{"search_expression": "mac & cheese (100% = ~good!)"},
+	}
+
+	// iterate through each test-case
+	for _, params := range testCases {
+		// run the next sub-test
+		t.Run(params["search_expression"]+params["starts_with"], func(t *testing.T) {
+			// signing is deterministic for a timestamp and nonce
+			apiURL, err := c.signURL("foods.search", params, "1500000000", "42")
+			if err != nil {
+				t.Fatalf("Could not sign url: '%v'", err)
+			}
+			again, _ := c.signURL("foods.search", params, "1500000000", "42")
+			if apiURL != again {
+				t.Errorf("got '%s' then '%s'; want identical urls", apiURL, again)
+			}
+
+			// the signed url verifies against the consumer secret
+			r := httptest.NewRequest(http.MethodGet, apiURL, nil)
+			if err := oauth1.Verify(r, "secret", ""); err != nil {
+				t.Errorf("got '%v' for '%s'; want a valid signature", err, apiURL)
+			}
+		})
+	}
+}
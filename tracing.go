@@ -0,0 +1,114 @@
+package fatsecret
+
+import (
+	"context"
+)
+
+// the span attribute keys
+const (
+	AttrMethod     = "fatsecret.method"          // the API method (ie: "food.get")
+	AttrFoodID     = "fatsecret.food_id"         // the requested food id
+	AttrQuery      = "fatsecret.query"           // the search expression
+	AttrBarcode    = "fatsecret.barcode"         // the requested barcode
+	AttrBrandType  = "fatsecret.brand_type"      // the requested brand type
+	AttrStartsWith = "fatsecret.starts_with"     // the brand starting character
+	AttrCategoryID = "fatsecret.category_id"     // the requested food category id
+	AttrAttempt    = "fatsecret.attempt"         // the http attempt, starting at zero
+	AttrErrorCode  = "fatsecret.error_code"      // the FatSecret error code
+	AttrStatusCode = "http.response.status_code" // the http status code
+)
+
+// Attribute is a key-value attribute of a span. The value is a string,
+// an int or a bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// StringAttribute returns a string span attribute
+func StringAttribute(key string, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// IntAttribute returns an int span attribute
+func IntAttribute(key string, value int) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer starts the spans which trace the client's API calls. It mirrors
+// the OpenTelemetry tracer, so an adapter only has to convert the
+// attributes. Implementations must be safe for concurrent use.
+type Tracer interface {
+	// Start starts a span as a child of any span in the context and
+	// returns the context holding the new span
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a traced operation started by a Tracer
+type Span interface {
+	// SetAttributes adds attributes to the span
+	SetAttributes(attrs ...Attribute)
+	// RecordError records the error as the span's failure status
+	RecordError(err error)
+	// End ends the span
+	End()
+}
+
+// WithTracer sets the tracer which traces the client's API calls. Each
+// logical call (ie: FoodByID) gets a span with a child span for each of
+// its http attempts. Tracing is disabled when no tracer is set.
+func WithTracer(tracer Tracer) Option {
+	return func(c *Client) {
+		c.tracer = tracer
+	}
+}
+
+// the span name prefix of the logical calls
+const spanPrefix = "fatsecret."
+
+// callSpanKey is the context key of the current logical call span
+type callSpanKey struct{}
+
+// noopSpan is the span used when tracing is disabled
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...Attribute) {}
+func (noopSpan) RecordError(err error)            {}
+func (noopSpan) End()                             {}
+
+// startCall starts the span of a logical call (ie: "FoodByID")
+func (c *Client) startCall(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := c.tracer.Start(ctx, spanPrefix+name, attrs...)
+	return context.WithValue(ctx, callSpanKey{}, span), span
+}
+
+// startAttempt starts the span of an http attempt of the API method
+func (c *Client) startAttempt(ctx context.Context, apiMethod string, attempt int) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return c.tracer.Start(ctx, apiMethod,
+		StringAttribute(AttrMethod, apiMethod),
+		IntAttribute(AttrAttempt, attempt),
+	)
+}
+
+// callSpan returns the logical call span of the context, if any
+func callSpan(ctx context.Context) Span {
+	if span, ok := ctx.Value(callSpanKey{}).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// endSpan records the error, if any, and ends the span. The error is
+// redacted first, since an API error can echo the oauth credentials.
+func (c *Client) endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(c.redactError("", err))
+	}
+	span.End()
+}
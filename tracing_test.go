@@ -0,0 +1,182 @@
+package fatsecret_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fitzone/fatsecret"
+	"github.com/fitzone/fatsecret/fatsecrettest"
+)
+
+// recordedSpan is a span recorded by the recordingTracer
+type recordedSpan struct {
+	name   string
+	parent *recordedSpan
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...fatsecret.Attribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *recordedSpan) End() {
+	s.ended = true
+}
+
+// recordingTracer records the started spans and their parents
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type spanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, attrs ...fatsecret.Attribute) (context.Context, fatsecret.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	span := &recordedSpan{name: name, parent: parent, attrs: map[string]interface{}{}}
+	span.SetAttributes(attrs...)
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestTracing(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+	srv.AddFood(fatsecret.FoodInfo{ID: "1", Name: "Apple"})
+	srv.AddBarcode("0748927052688", "1")
+
+	// the first food.get attempt fails with a 503 and is retried
+	srv.InjectFault("food.get", fatsecrettest.Fault{Status: http.StatusServiceUnavailable, Count: 1})
+	tracer := &recordingTracer{}
+	client, err := srv.NewClient(fatsecret.WithTracer(tracer), fatsecret.WithRetry(1, time.Millisecond))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+
+	// the caller's span is the parent of the call spans
+	ctx, root := tracer.Start(context.Background(), "caller")
+	if _, err := client.FoodByBarcode(ctx, "748927052688"); err != nil {
+		t.Fatalf("Could not fetch food: '%v'", err)
+	}
+	root.End()
+
+	// verify the span tree
+	tests := []struct {
+		name    string
+		parent  string
+		attrKey string
+		attrVal interface{}
+	}{
+		{"caller", "", "", nil},
+		{"fatsecret.FoodByBarcode", "caller", fatsecret.AttrBarcode, "748927052688"},
+		{"fatsecret.FoodIDForBarcode", "fatsecret.FoodByBarcode", fatsecret.AttrBarcode, "748927052688"},
+		{"food.find_id_for_barcode", "fatsecret.FoodIDForBarcode", fatsecret.AttrAttempt, 0},
+		{"fatsecret.FoodByID", "fatsecret.FoodByBarcode", fatsecret.AttrFoodID, "1"},
+		{"food.get", "fatsecret.FoodByID", fatsecret.AttrStatusCode, 503},
+		{"food.get", "fatsecret.FoodByID", fatsecret.AttrAttempt, 1},
+	}
+	if len(tracer.spans) != len(tests) {
+		t.Fatalf("got %d spans; want %d", len(tracer.spans), len(tests))
+	}
+	for i, tt := range tests {
+		span := tracer.spans[i]
+		parent := ""
+		if span.parent != nil {
+			parent = span.parent.name
+		}
+		if span.name != tt.name || parent != tt.parent {
+			t.Errorf("got span '%s' with parent '%s'; want '%s' with parent '%s'", span.name, parent, tt.name, tt.parent)
+		}
+		if tt.attrKey != "" && span.attrs[tt.attrKey] != tt.attrVal {
+			t.Errorf("got %s '%v' on span '%s'; want '%v'", tt.attrKey, span.attrs[tt.attrKey], span.name, tt.attrVal)
+		}
+		if !span.ended {
+			t.Errorf("got span '%s' not ended; want it ended", span.name)
+		}
+	}
+	if tracer.spans[5].err == nil {
+		t.Errorf("got no error on the failed attempt; want an error")
+	}
+}
+
+func TestTracingErrorCode(t *testing.T) {
+	srv := fatsecrettest.NewServer("test-key", "test-secret")
+	defer srv.Close()
+
+	// fetch an unknown food
+	tracer := &recordingTracer{}
+	client, err := srv.NewClient(fatsecret.WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if _, err := client.FoodByID("2"); err == nil {
+		t.Fatalf("got no error; want an invalid id error")
+	}
+
+	// the error code is on both the call and the attempt spans
+	if len(tracer.spans) != 2 {
+		t.Fatalf("got %d spans; want 2", len(tracer.spans))
+	}
+	for _, span := range tracer.spans {
+		if got := span.attrs[fatsecret.AttrErrorCode]; got != fatsecrettest.ErrorCodeInvalidID {
+			t.Errorf("got error code '%v' on span '%s'; want %d", got, span.name, fatsecrettest.ErrorCodeInvalidID)
+		}
+	}
+	if tracer.spans[0].err == nil {
+		t.Errorf("got no error on the call span; want an error")
+	}
+}
+
+func TestTracingRedactsErrors(t *testing.T) {
+	// an api which echoes the signature in its error, like the live api
+	mu := sync.Mutex{}
+	signature := ""
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		mu.Lock()
+		signature = params.Get("oauth_signature")
+		mu.Unlock()
+		fmt.Fprintf(w, `{"error": {"code": 8, "message": "Invalid signature: oauth_signature '%s' (oauth_consumer_key=%s)"}}`,
+			params.Get("oauth_signature"), params.Get("oauth_consumer_key"))
+	}))
+	defer api.Close()
+
+	tracer := &recordingTracer{}
+	client, err := fatsecret.NewClient("secret-key", "wrong-secret",
+		fatsecret.WithAPIURL(api.URL), fatsecret.WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("Could not create client: '%v'", err)
+	}
+	if _, err := client.FoodByID("1"); err == nil {
+		t.Fatalf("got no error; want an invalid signature error")
+	}
+
+	// the signature and consumer key are redacted from the recorded error
+	mu.Lock()
+	defer mu.Unlock()
+	if len(tracer.spans) != 2 || tracer.spans[0].err == nil {
+		t.Fatalf("got spans %+v; want the call span with an error", tracer.spans)
+	}
+	got := tracer.spans[0].err.Error()
+	for _, secret := range []string{signature, "secret-key"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("got span error '%s'; want '%s' redacted", got, secret)
+		}
+	}
+}